@@ -0,0 +1,25 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+)
+
+// newLogger returns a logger writing JSON lines to stderr at level and above
+func newLogger(level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// envLogLevel reads a log level (debug, info, warn or error) from the environment or returns info
+func envLogLevel(key string) slog.Level {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return slog.LevelInfo
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(raw)); err != nil {
+		slog.Warn("Invalid environment value, using default", "key", key, "value", raw, "default", slog.LevelInfo.String())
+		return slog.LevelInfo
+	}
+	return level
+}
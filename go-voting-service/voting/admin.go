@@ -0,0 +1,37 @@
+package voting
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// adminAuth requires an Authorization: Bearer header matching token. Without a configured
+// token the admin routes are disabled and answer 404 rather than being left open.
+func adminAuth(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			writeJSONError(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+			return
+		}
+		if checkAdmin(w, r, token) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// checkAdmin reports whether r carries token as a bearer credential, writing a 401 or 403
+// response when it does not
+func checkAdmin(w http.ResponseWriter, r *http.Request, token string) bool {
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || provided == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSONError(w, http.StatusUnauthorized, "Missing bearer token")
+		return false
+	}
+	if token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+		writeJSONError(w, http.StatusForbidden, "Invalid bearer token")
+		return false
+	}
+	return true
+}
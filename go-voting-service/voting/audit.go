@@ -0,0 +1,134 @@
+package voting
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Actions recorded in the audit log
+const (
+	auditActionVote    = "vote"    // a vote was counted
+	auditActionRetract = "retract" // a change-mode vote was taken back from the candidate it replaced
+	auditActionUnvote  = "unvote"  // an admin removed one vote
+	auditActionReset   = "reset"   // every count was zeroed
+)
+
+// AuditRecord is one line of the audit log. Replaying the vote, retract, unvote and reset
+// records in order reproduces the counts.
+type AuditRecord struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`
+	Candidate string    `json:"candidate,omitempty"`
+	Voter     string    `json:"voter,omitempty"` // only the voter ID the caller supplied
+	IP        string    `json:"ip,omitempty"`
+	Weight    int       `json:"weight,omitempty"` // votes added or, for retract and unvote, removed
+}
+
+// auditLog appends AuditRecords as JSON lines to a file. Lines go through a buffer flushed every
+// AuditFlushInterval and on Stop, so vote processing rarely waits on the disk. Write errors are
+// logged and the buffered lines dropped; they never stop the service or the vote being counted.
+type auditLog struct {
+	mu     sync.Mutex
+	file   *os.File
+	buf    *bufio.Writer
+	failed bool // the last write failed; logged once until a flush succeeds again
+	closed bool
+}
+
+// openAuditLog opens path for appending, creating it if needed
+func openAuditLog(path string) (*auditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLog{file: f, buf: bufio.NewWriter(f)}, nil
+}
+
+// write buffers rec as one line
+func (a *auditLog) write(rec AuditRecord) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		slog.Error("Failed to encode audit record", "error", err)
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed {
+		return
+	}
+	if _, err := a.buf.Write(append(line, '\n')); err != nil {
+		a.fail(err)
+	}
+}
+
+// flush writes the buffered lines to the file
+func (a *auditLog) flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.closed || a.buf.Buffered() == 0 {
+		return
+	}
+	if err := a.buf.Flush(); err != nil {
+		a.fail(err)
+		return
+	}
+	if a.failed {
+		a.failed = false
+		slog.Info("Audit log writes recovered")
+	}
+}
+
+// close flushes the buffer and closes the file; later writes are ignored
+func (a *auditLog) close() {
+	a.flush()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	if err := a.file.Close(); err != nil {
+		slog.Error("Failed to close audit log", "error", err)
+	}
+}
+
+// fail reports a write error and discards the buffer, whose error would otherwise stick;
+// callers hold a.mu
+func (a *auditLog) fail(err error) {
+	if !a.failed {
+		slog.Error("Audit log write failed, dropping buffered records", "error", err)
+	}
+	a.failed = true
+	a.buf.Reset(a.file)
+}
+
+// auditRecord writes rec to the audit log; it is a no-op unless AUDIT_LOG_FILE is set
+func (vm *VoteManager) auditRecord(rec AuditRecord) {
+	if vm.audit == nil {
+		return
+	}
+	vm.audit.write(rec)
+}
+
+// auditVote records a counted vote
+func (vm *VoteManager) auditVote(v vote, now time.Time, weight int) {
+	vm.auditRecord(AuditRecord{Time: now, Action: auditActionVote, Candidate: v.candidate, Voter: v.identity, IP: v.ip, Weight: weight})
+}
+
+// auditRetraction records the votes v's change took back from candidate
+func (vm *VoteManager) auditRetraction(v vote, candidate string, now time.Time, taken int) {
+	vm.auditRecord(AuditRecord{Time: now, Action: auditActionRetract, Candidate: candidate, Voter: v.identity, IP: v.ip, Weight: taken})
+}
+
+// runAuditFlusher flushes the audit log every AuditFlushInterval; Stop does the final flush
+func (vm *VoteManager) runAuditFlusher() {
+	for {
+		select {
+		case <-vm.clock.After(vm.cfg.AuditFlushInterval):
+			vm.audit.flush()
+		case <-vm.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package voting
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAuditLogExplainsCounts(t *testing.T) {
+	cfg := testConfig()
+	cfg.Poll.VoterMode = voterModeChange
+	cfg.AuditLogFile = filepath.Join(t.TempDir(), "audit.jsonl")
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+
+	vote := func(body string) {
+		t.Helper()
+		if rec := serve(h, http.MethodPost, "/vote", body); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote %s: status %d, body %s", body, rec.Code, rec.Body)
+		}
+	}
+	vote(`{"candidate":"Candidate A","voter":"alice"}`)
+	// Anonymous, so keyed by a voter cookie that must not reach the log
+	vote(`{"candidate":"Candidate B"}`)
+	waitFor(t, "the first votes", func() bool { return votesFor(vm, "Candidate A") == 1 && votesFor(vm, "Candidate B") == 1 })
+	vote(`{"candidate":"Candidate B","voter":"alice"}`)
+	waitFor(t, "the changed vote", func() bool { return votesFor(vm, "Candidate B") == 2 })
+	if rec := serve(h, http.MethodPost, "/unvote?candidate=Candidate+B", "", adminHeader...); rec.Code != http.StatusOK {
+		t.Fatalf("unvote: status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := serve(h, http.MethodPost, "/reset", "", adminHeader...); rec.Code != http.StatusOK {
+		t.Fatalf("reset: status %d, body %s", rec.Code, rec.Body)
+	}
+	vm.Stop()
+
+	f, err := os.Open(cfg.AuditLogFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []AuditRecord
+	for scanner := bufio.NewScanner(f); scanner.Scan(); {
+		var rec AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("invalid audit line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, rec)
+	}
+
+	want := []AuditRecord{
+		{Action: auditActionVote, Candidate: "Candidate A", Voter: "alice", Weight: 1},
+		{Action: auditActionVote, Candidate: "Candidate B", Weight: 1},
+		{Action: auditActionVote, Candidate: "Candidate B", Voter: "alice", Weight: 1},
+		{Action: auditActionRetract, Candidate: "Candidate A", Voter: "alice", Weight: 1},
+		{Action: auditActionUnvote, Candidate: "Candidate B", Weight: 1},
+		{Action: auditActionReset},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("audit log has %d records, want %d: %+v", len(got), len(want), got)
+	}
+	for i, rec := range got {
+		rec.Time, rec.IP = want[i].Time, ""
+		if rec != want[i] {
+			t.Errorf("record %d is %+v, want %+v", i, rec, want[i])
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package voting
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// defaultPollID identifies the poll served by the top-level endpoints
+const defaultPollID = "default"
+
+// maxBatchBodyBytes bounds the /results/batch request body
+const maxBatchBodyBytes = 64 << 10
+
+// BatchRequest is the body accepted by POST /results/batch
+type BatchRequest struct {
+	Polls []string `json:"polls"`
+}
+
+// PollResults is one poll's entry in a batch response; Error is set for unknown polls
+type PollResults struct {
+	Poll       string       `json:"poll"`
+	Candidates []*Candidate `json:"candidates,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// pollResults returns a consistent snapshot of a poll's results, if the poll exists
+func (reg *PollRegistry) pollResults(id string) ([]*Candidate, bool) {
+	p, ok := reg.Get(id)
+	if !ok {
+		return nil, false
+	}
+	return p.results(), true
+}
+
+// batchResultsHandler returns the results of several polls in one response
+func (reg *PollRegistry) batchResultsHandler(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid batch request body")
+		return
+	}
+	if len(req.Polls) == 0 {
+		writeJSONError(w, http.StatusBadRequest, "At least one poll ID is required")
+		return
+	}
+
+	results := make([]PollResults, 0, len(req.Polls))
+	for _, id := range req.Polls {
+		candidates, ok := reg.pollResults(id)
+		if !ok {
+			results = append(results, PollResults{Poll: id, Error: "poll not found"})
+			continue
+		}
+		results = append(results, PollResults{Poll: id, Candidates: candidates})
+	}
+	writeJSON(w, http.StatusOK, results)
+}
@@ -0,0 +1,49 @@
+package voting
+
+import (
+	"maps"
+	"net/http"
+	"testing"
+)
+
+func TestBatchResultsForTwoPolls(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	if rec := serve(h, http.MethodPost, "/admin/polls", `{"id":"lunch","candidates":["Pizza","Tacos"]}`, adminHeader...); rec.Code != http.StatusCreated {
+		t.Fatalf("creating poll: status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+		t.Fatalf("vote: status %d", rec.Code)
+	}
+	if rec := serve(h, http.MethodPost, "/polls/lunch/vote", `{"candidate":"Tacos"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("lunch vote: status %d, body %s", rec.Code, rec.Body)
+	}
+	lunch, _ := vm.polls.Get("lunch")
+	waitFor(t, "votes", func() bool { return votesFor(vm, "Candidate A") == 1 && votesFor(lunch.VoteManager, "Tacos") == 1 })
+
+	rec := serve(h, http.MethodPost, "/results/batch", `{"polls":["default","lunch","missing"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+	}
+	var results []PollResults
+	decodeJSON(t, rec, &results)
+	if len(results) != 3 {
+		t.Fatalf("%d results, want 3: %s", len(results), rec.Body)
+	}
+	want := map[string]map[string]int{
+		"default": {"Candidate A": 1, "Candidate B": 0},
+		"lunch":   {"Pizza": 0, "Tacos": 1},
+	}
+	for _, r := range results[:2] {
+		got := make(map[string]int)
+		for _, c := range r.Candidates {
+			got[c.Name] = c.Votes
+		}
+		if !maps.Equal(got, want[r.Poll]) {
+			t.Errorf("poll %s results %v, want %v", r.Poll, got, want[r.Poll])
+		}
+	}
+	if results[2].Poll != "missing" || results[2].Error == "" {
+		t.Errorf("unknown poll entry %+v, want an error", results[2])
+	}
+}
@@ -0,0 +1,315 @@
+package voting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCandidateNameLength bounds candidate names accepted from clients
+const maxCandidateNameLength = 256
+
+// Limits on candidate metadata accepted from admins
+const (
+	maxDescriptionLength = 1024
+	maxColorLength       = 32
+	maxImageURLLength    = 2048
+)
+
+var (
+	errCandidateNameRequired = errors.New("candidate name is required")
+	errCandidateNameTooLong  = errors.New("candidate name is too long")
+	errCandidateLimit        = errors.New("candidate limit reached")
+	errCandidateNotFound     = errors.New("candidate not found")
+	errDuplicateCandidate    = errors.New("duplicate candidate name")
+	errCandidateExists       = errors.New("candidate already exists")
+	errStopped               = errors.New("vote manager is stopped")
+	errDescriptionTooLong    = errors.New("description is too long")
+	errColorTooLong          = errors.New("color is too long")
+	errInvalidImageURL       = errors.New("imageUrl must be an http or https URL")
+)
+
+// CandidateInfo is a candidate's optional display metadata. It is set when the candidate is
+// added and never changed by voting.
+type CandidateInfo struct {
+	Description string `json:"description,omitempty"`
+	Color       string `json:"color,omitempty"`
+	ImageURL    string `json:"imageUrl,omitempty"`
+}
+
+// validate rejects over-long fields and image URLs that are not http or https, which browsers
+// could otherwise be tricked into running
+func (info CandidateInfo) validate() error {
+	if utf8.RuneCountInString(info.Description) > maxDescriptionLength {
+		return errDescriptionTooLong
+	}
+	if len(info.Color) > maxColorLength {
+		return errColorTooLong
+	}
+	if info.ImageURL != "" {
+		u, err := url.Parse(info.ImageURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(info.ImageURL) > maxImageURLLength {
+			return errInvalidImageURL
+		}
+	}
+	return nil
+}
+
+// validateCandidateName rejects empty, whitespace-only and over-long names
+func validateCandidateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return errCandidateNameRequired
+	}
+	if utf8.RuneCountInString(name) > maxCandidateNameLength {
+		return errCandidateNameTooLong
+	}
+	return nil
+}
+
+// loadCandidatesFile reads the initial candidates from path, a JSON array of objects with a
+// name, optional starting votes and optional CandidateInfo fields. It returns nil without an error when the file does not exist.
+func loadCandidatesFile(path string, maxCandidates int) (map[string]*Candidate, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []savedCandidate
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("parse %s: no candidates", path)
+	}
+	if len(entries) > maxCandidates {
+		return nil, fmt.Errorf("parse %s: %w", path, errCandidateLimit)
+	}
+	candidates := make(map[string]*Candidate, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSpace(entry.Name)
+		if err := validateCandidateName(name); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+		if _, exists := candidates[name]; exists {
+			return nil, fmt.Errorf("parse %s: %w %q", path, errDuplicateCandidate, name)
+		}
+		if entry.Votes < 0 {
+			return nil, fmt.Errorf("parse %s: negative votes for %q", path, name)
+		}
+		if err := entry.CandidateInfo.validate(); err != nil {
+			return nil, fmt.Errorf("parse %s: %q: %w", path, name, err)
+		}
+		candidates[name] = &Candidate{Name: name, Votes: entry.Votes, CandidateInfo: entry.CandidateInfo}
+	}
+	return candidates, nil
+}
+
+// initialCandidates returns the candidates a poll starts with: those in CandidatesFile if it
+// exists, otherwise Candidate A and Candidate B. An invalid file is an error, never a reason to
+// run the poll with the defaults.
+func initialCandidates(cfg Config) (map[string]*Candidate, error) {
+	if cfg.CandidatesFile != "" {
+		candidates, err := loadCandidatesFile(cfg.CandidatesFile, cfg.MaxCandidates)
+		if err != nil {
+			return nil, err
+		}
+		if candidates != nil {
+			slog.Info("Loaded candidates", "path", cfg.CandidatesFile, "count", len(candidates))
+			return candidates, nil
+		}
+	}
+	return map[string]*Candidate{
+		"Candidate A": {Name: "Candidate A", Votes: 0},
+		"Candidate B": {Name: "Candidate B", Votes: 0},
+	}, nil
+}
+
+// AddCandidate registers a new candidate with no votes on the vote-processing goroutine
+// and broadcasts the new candidate list
+func (vm *VoteManager) AddCandidate(name string, info CandidateInfo) error {
+	name = strings.TrimSpace(name)
+	if err := validateCandidateName(name); err != nil {
+		return err
+	}
+	if err := info.validate(); err != nil {
+		return err
+	}
+	var err error
+	ok := vm.do(func() {
+		vm.mu.Lock()
+		switch _, exists := vm.candidates[name]; {
+		case exists:
+			err = errCandidateExists
+		case len(vm.candidates) >= vm.cfg.MaxCandidates:
+			err = errCandidateLimit
+		default:
+			vm.candidates[name] = &Candidate{Name: name, CandidateInfo: info}
+			vm.dirty.Store(true)
+		}
+		vm.mu.Unlock()
+		if err == nil {
+			vm.notifyClients(eventCandidateAdded)
+		}
+	})
+	if !ok {
+		return errStopped
+	}
+	return err
+}
+
+// RemoveCandidate deletes a candidate. It runs on the vote-processing goroutine after every
+// buffered vote has been applied, so votes accepted for the candidate are counted before it goes.
+func (vm *VoteManager) RemoveCandidate(name string) error {
+	var err error
+	ok := vm.do(func() {
+		vm.mu.Lock()
+		if _, exists := vm.candidates[name]; !exists {
+			err = errCandidateNotFound
+		} else {
+			delete(vm.candidates, name)
+			vm.dirty.Store(true)
+		}
+		vm.mu.Unlock()
+		if err == nil {
+			vm.notifyClients(eventCandidateRemoved)
+		}
+	})
+	if !ok {
+		return errStopped
+	}
+	return err
+}
+
+// CandidateRequest is the body accepted by POST /candidates
+type CandidateRequest struct {
+	Name string `json:"name"`
+	CandidateInfo
+}
+
+// addCandidateHandler registers a candidate; 409 if it already exists or the limit is reached
+func (vm *VoteManager) addCandidateHandler(w http.ResponseWriter, r *http.Request) {
+	if vm.cfg.ReadOnly {
+		writeJSONError(w, http.StatusServiceUnavailable, "Editing candidates is unavailable: server is read-only")
+		return
+	}
+	var req CandidateRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxVoteBodyBytes)).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid candidate body")
+		return
+	}
+	switch err := vm.AddCandidate(req.Name, req.CandidateInfo); err {
+	case nil:
+		writeJSON(w, http.StatusCreated, Candidate{Name: strings.TrimSpace(req.Name), CandidateInfo: req.CandidateInfo})
+	case errCandidateExists, errCandidateLimit:
+		writeJSONError(w, http.StatusConflict, err.Error())
+	case errStopped:
+		writeJSONError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	}
+}
+
+// removeCandidateHandler deletes the candidate named by ?name
+func (vm *VoteManager) removeCandidateHandler(w http.ResponseWriter, r *http.Request) {
+	if vm.cfg.ReadOnly {
+		writeJSONError(w, http.StatusServiceUnavailable, "Editing candidates is unavailable: server is read-only")
+		return
+	}
+	switch err := vm.RemoveCandidate(strings.TrimSpace(r.URL.Query().Get("name"))); err {
+	case nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errCandidateNotFound:
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	default:
+		writeJSONError(w, http.StatusServiceUnavailable, err.Error())
+	}
+}
+
+// CandidateSet is the body accepted by PUT /admin/candidates
+type CandidateSet struct {
+	Candidates []string `json:"candidates"`
+}
+
+// ReplaceCandidates swaps in a new candidate set. Candidates kept from the old set keep their
+// counts; the rest start at zero. The new map is built first and installed with a single
+// assignment under the write lock, so readers see either the whole old set or the whole new one.
+func (vm *VoteManager) ReplaceCandidates(names []string) error {
+	next := make(map[string]*Candidate, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if err := validateCandidateName(name); err != nil {
+			return err
+		}
+		if _, dup := next[name]; dup {
+			return errDuplicateCandidate
+		}
+		next[name] = &Candidate{Name: name}
+	}
+	if len(next) > vm.cfg.MaxCandidates {
+		return errCandidateLimit
+	}
+
+	ok := vm.do(func() {
+		vm.mu.Lock()
+		for name := range next {
+			if old, exists := vm.candidates[name]; exists {
+				next[name] = old
+			}
+		}
+		vm.candidates = next
+		vm.dirty.Store(true)
+		vm.mu.Unlock()
+		vm.notifyClients(eventSnapshot)
+	})
+	if !ok {
+		return errStopped
+	}
+	return nil
+}
+
+// replaceCandidatesHandler swaps the candidate set and returns the new results
+func (vm *VoteManager) replaceCandidatesHandler(w http.ResponseWriter, r *http.Request) {
+	if vm.cfg.ReadOnly {
+		writeJSONError(w, http.StatusServiceUnavailable, "Editing candidates is unavailable: server is read-only")
+		return
+	}
+	var set CandidateSet
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)).Decode(&set); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid candidate set")
+		return
+	}
+	switch err := vm.ReplaceCandidates(set.Candidates); err {
+	case nil:
+		writeJSON(w, http.StatusOK, vm.snapshot())
+	case errCandidateLimit:
+		writeJSONError(w, http.StatusConflict, err.Error())
+	case errStopped:
+		writeJSONError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	}
+}
+
+// hasCandidate reports whether name is a registered candidate
+func (vm *VoteManager) hasCandidate(name string) bool {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+	_, exists := vm.candidates[name]
+	return exists
+}
+
+// candidateCount returns the number of registered candidates
+func (vm *VoteManager) candidateCount() int {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+	return len(vm.candidates)
+}
@@ -0,0 +1,224 @@
+package voting
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAutoCreateCandidateOnFirstVote(t *testing.T) {
+	for _, autoCreate := range []bool{true, false} {
+		cfg := testConfig()
+		cfg.AutoCreateCandidates = autoCreate
+		vm := startManager(t, cfg)
+
+		rec := postVote(vm.Handler(), "Write-in")
+		if !autoCreate {
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("auto-create off: status %d, want 404", rec.Code)
+			}
+			if votesFor(vm, "Write-in") != -1 {
+				t.Error("auto-create off created the candidate")
+			}
+			continue
+		}
+		if rec.Code != http.StatusAccepted {
+			t.Fatalf("auto-create on: status %d, body %s", rec.Code, rec.Body)
+		}
+		waitFor(t, "the write-in candidate", func() bool { return votesFor(vm, "Write-in") == 1 })
+	}
+}
+
+// holdProcessing blocks the vote-processing goroutine until the returned release is called,
+// so votes posted meanwhile stay buffered in the vote channel
+func holdProcessing(vm *VoteManager) (release func()) {
+	held, done := make(chan struct{}), make(chan struct{})
+	go vm.do(func() {
+		close(held)
+		<-done
+	})
+	<-held
+	return func() { close(done) }
+}
+
+func TestRemoveCandidateCountsBufferedVotesFirst(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	if err := vm.AddCandidate("X", CandidateInfo{}); err != nil {
+		t.Fatal(err)
+	}
+
+	release := holdProcessing(vm)
+	if rec := postVote(h, "X"); rec.Code != http.StatusAccepted {
+		t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+	}
+	removed := make(chan error, 1)
+	go func() { removed <- vm.RemoveCandidate("X") }()
+	release()
+	if err := <-removed; err != nil {
+		t.Fatalf("RemoveCandidate: %v", err)
+	}
+
+	if votesFor(vm, "X") != -1 {
+		t.Error("X still registered after removal")
+	}
+	// The accepted vote was counted before X went, so the history has it
+	counted := 0
+	for _, c := range vm.history.countBetween(time.Time{}, time.Now().Add(time.Hour)) {
+		if c.Name == "X" {
+			counted = c.Votes
+		}
+	}
+	if counted != 1 {
+		t.Errorf("X's buffered vote counted %d times before removal, want 1", counted)
+	}
+}
+
+func TestWriteInFloodIsThrottledAndCapped(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		burst, max    int
+		created       int
+		rejected      int
+		wantRetryHint bool
+	}{
+		{name: "throttled", burst: 3, max: 100, created: 3, rejected: http.StatusTooManyRequests, wantRetryHint: true},
+		{name: "capped", burst: 100, max: 4, created: 2, rejected: http.StatusConflict},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.AutoCreateCandidates = true
+			cfg.CandidateCreateRate = 1
+			cfg.CandidateCreateBurst = tt.burst
+			cfg.MaxCandidates = tt.max
+			// The fake clock never moves, so the creation bucket never refills
+			vm := startManagerWithClock(t, cfg, newFakeClock())
+			h := vm.Handler()
+
+			for i := range 10 {
+				name := fmt.Sprintf("Write-in %d", i)
+				rec := postVote(h, name)
+				want := http.StatusAccepted
+				if i >= tt.created {
+					want = tt.rejected
+				}
+				if rec.Code != want {
+					t.Fatalf("write-in %d: status %d, want %d", i, rec.Code, want)
+				}
+				if i >= tt.created && tt.wantRetryHint && rec.Header().Get("Retry-After") == "" {
+					t.Errorf("write-in %d: no Retry-After", i)
+				}
+				// The cap counts created candidates, so let each creation land before the next
+				if rec.Code == http.StatusAccepted {
+					waitFor(t, name, func() bool { return votesFor(vm, name) == 1 })
+				}
+			}
+			if n := len(vm.candidateList()); n != 2+tt.created {
+				t.Errorf("%d candidates after the flood, want %d", n, 2+tt.created)
+			}
+
+			for _, name := range []string{"Candidate A", "Write-in 0"} {
+				if rec := postVote(h, name); rec.Code != http.StatusAccepted {
+					t.Errorf("vote for existing %s during the flood: status %d", name, rec.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestResultsDuringCandidateSwapSeeOneSet(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	sets := [][]string{{"Left", "Right"}, {"Red", "Green", "Blue"}}
+	if err := vm.ReplaceCandidates(sets[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := make(chan struct{})
+	swapped := make(chan struct{})
+	go func() {
+		defer close(swapped)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := vm.ReplaceCandidates(sets[i%2]); err != nil {
+				t.Errorf("ReplaceCandidates: %v", err)
+				return
+			}
+			// The swaps hand off to the processing goroutine and back; on a single CPU that
+			// pair would otherwise keep the reader from being scheduled
+			runtime.Gosched()
+		}
+	}()
+
+	for range 200 {
+		var results ResultsPayload
+		decodeJSON(t, serve(h, http.MethodGet, "/results?sort=name", ""), &results)
+		got := names(results.Candidates)
+		consistent := false
+		for _, set := range sets {
+			want := slices.Sorted(slices.Values(set))
+			consistent = consistent || slices.Equal(got, want)
+		}
+		if !consistent {
+			t.Errorf("results mix candidate sets: %v", got)
+			break
+		}
+	}
+	close(stop)
+	<-swapped
+}
+
+func TestAddAndRemoveCandidatesOverHTTP(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	stream := openStream(t, newServer(t, h).URL+"/events")
+	stream.nextOf(t, eventSnapshot)
+
+	if rec := serve(h, http.MethodPost, "/candidates", `{"name":"Candidate C"}`, adminHeader...); rec.Code != http.StatusCreated {
+		t.Fatalf("add: status %d, body %s", rec.Code, rec.Body)
+	}
+	added := stream.nextOf(t, eventCandidateAdded).results(t)
+	if got := names(added.Candidates); !slices.Contains(got, "Candidate C") {
+		t.Errorf("candidate_added lists %v, want Candidate C among them", got)
+	}
+	if rec := serve(h, http.MethodPost, "/candidates", `{"name":"Candidate C"}`, adminHeader...); rec.Code != http.StatusConflict {
+		t.Errorf("duplicate add: status %d, want 409", rec.Code)
+	}
+
+	if rec := serve(h, http.MethodDelete, "/candidates?name=Candidate+A", "", adminHeader...); rec.Code != http.StatusNoContent {
+		t.Fatalf("remove: status %d, body %s", rec.Code, rec.Body)
+	}
+	removed := stream.nextOf(t, eventCandidateRemoved).results(t)
+	if got := names(removed.Candidates); slices.Contains(got, "Candidate A") {
+		t.Errorf("candidate_removed still lists Candidate A: %v", got)
+	}
+	if rec := serve(h, http.MethodDelete, "/candidates?name=Nobody", "", adminHeader...); rec.Code != http.StatusNotFound {
+		t.Errorf("removing an unknown candidate: status %d, want 404", rec.Code)
+	}
+}
+
+func TestInvalidCandidatesFileFailsNewVoteManager(t *testing.T) {
+	cfg := testConfig()
+	cfg.CandidatesFile = filepath.Join(t.TempDir(), "candidates.json")
+	if err := os.WriteFile(cfg.CandidatesFile, []byte(`[{"name":"Red"},{"name":"Red"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if vm, err := NewVoteManager(cfg); err == nil {
+		t.Errorf("NewVoteManager accepted a duplicate candidate and started with %v", names(vm.candidateList()))
+	}
+
+	// A missing file still means the defaults
+	cfg.CandidatesFile = filepath.Join(t.TempDir(), "missing.json")
+	if _, err := NewVoteManager(cfg); err != nil {
+		t.Errorf("missing candidates file: %v", err)
+	}
+}
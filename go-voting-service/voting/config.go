@@ -0,0 +1,617 @@
+package voting
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSSEFlushDelay bounds how long the first SSE flush may be held back
+const maxSSEFlushDelay = time.Second
+
+// PollConfig holds the identity rules a poll enforces on its voters. Each poll has its own, so
+// anonymous and identified polls can run side by side.
+type PollConfig struct {
+	RequireVoterID bool `json:"requireVoterId"` // reject votes that carry no voter ID
+	MaxPicks       int  `json:"maxPicks"`       // distinct candidates a voter may pick; 0 disables tracking
+
+	// VoterMode is unlimited, once or change; the latter two key on ?voter or a voter cookie
+	VoterMode string `json:"voterMode"`
+}
+
+var errInvalidVoterMode = errors.New("voter mode must be one of unlimited, once or change")
+
+// validate checks the rules of a poll created over the API
+func (p PollConfig) validate() error {
+	if !slices.Contains([]string{voterModeUnlimited, voterModeOnce, voterModeChange}, p.VoterMode) {
+		return errInvalidVoterMode
+	}
+	if p.MaxPicks < 0 {
+		return errors.New("maxPicks must not be negative")
+	}
+	return nil
+}
+
+// CORSConfig controls which browser origins may call the API
+type CORSConfig struct {
+	Origins          []string // allowed origins; "*" allows any origin
+	AllowCredentials bool     // send Access-Control-Allow-Credentials; not allowed with "*"
+
+	// MaxAge lets browsers cache a preflight answer, so votes are not each preceded by an OPTIONS
+	MaxAge time.Duration
+}
+
+// Config holds the runtime settings read from the environment
+type Config struct {
+	MaxHeaderBytes int
+	MinBuffer      int
+	NumCPU         int
+
+	// VoteBuffer sizes the vote channel; a bigger one absorbs bursts instead of answering 503.
+	// ClientBuffer sizes each SSE client's channel; a bigger one drops fewer slow clients but
+	// holds more events in memory per connection. 0 uses bufferSize for either.
+	VoteBuffer   int
+	ClientBuffer int
+
+	ReadOnly       bool
+	OpenAt         time.Time
+	Deadline       time.Time // voting closes automatically at this time when set
+	PreVoteMode    string
+	PreVoteCap     int
+	HistorySize    int
+	SSEFlushDelay  time.Duration
+	ListenAddr     string
+	PingInterval   time.Duration
+	MaxCandidates  int
+	HandlerTimeout time.Duration
+
+	// CandidatesFile seeds the candidates at startup; without it the poll starts with
+	// Candidate A and Candidate B. Counts saved in DataFile take precedence over its counts.
+	CandidatesFile string
+
+	DecayHalfLife  time.Duration // 0 disables decayedVotes
+	ShedThreshold  float64       // fraction of the vote buffer at which votes get 429; 0 disables
+	MilestoneEvery int           // announce a milestone event every N votes per candidate; 0 disables
+
+	// Maintenance serves 503 with MaintenanceMessage and a Retry-After of MaintenanceRetryAfter
+	// from every endpoint but /healthz, without processing votes
+	Maintenance           bool
+	MaintenanceMessage    string
+	MaintenanceRetryAfter time.Duration
+
+	// SSETokenRotation is how often SSE session tokens are replaced; 0 disables session tokens.
+	// A replaced token stays valid for SSETokenGrace so reconnects in flight still succeed.
+	SSETokenRotation time.Duration
+	SSETokenGrace    time.Duration
+
+	// DataFile persists the vote counts across restarts; empty keeps them in memory only.
+	// Counts are saved at most every SaveInterval and once more on shutdown.
+	DataFile     string
+	SaveInterval time.Duration
+
+	// AuditLogFile receives one JSON line per counted, retracted or removed vote and per reset,
+	// for dispute resolution; empty disables the log. Lines are buffered and flushed every
+	// AuditFlushInterval and on shutdown.
+	AuditLogFile       string
+	AuditFlushInterval time.Duration
+
+	// OTLPEndpoint is where vote-handling spans are exported over OTLP/HTTP; empty disables
+	// tracing. The exporter reads the other standard OTEL_EXPORTER_OTLP_* variables itself.
+	OTLPEndpoint string
+
+	// ExportURL receives the counts as InfluxDB line protocol every ExportInterval, e.g.
+	// http://influx:8086/api/v2/write?org=o&bucket=b&precision=ns; empty disables the export.
+	// ExportToken, if set, is sent as an InfluxDB API token and is never exposed by /config.
+	ExportURL      string
+	ExportInterval time.Duration
+	ExportToken    string
+
+	// SSEReplayBuffer is how many recent events are kept to replay after a Last-Event-ID reconnect;
+	// 0 always sends reconnecting clients the full snapshot
+	SSEReplayBuffer int
+
+	// LongPollTimeout is how long /results/poll waits for a change before answering 204
+	LongPollTimeout time.Duration
+
+	// MaxSSEClients caps concurrent /events connections per poll; 0 means unlimited
+	MaxSSEClients int
+
+	// SlowClientMisses disconnects an SSE client after this many consecutive events are dropped
+	// because it is not keeping up; it then reconnects and resyncs. 0 keeps slow clients connected.
+	SlowClientMisses int
+
+	// SSELogEvery logs a progress line per SSE connection after every N delivered events; 0 disables it
+	SSELogEvery int
+
+	// CandidateThrottle is the minimum gap between update events for the same candidate; 0 disables
+	CandidateThrottle time.Duration
+
+	// BroadcastInterval coalesces vote updates into at most one event per interval; 0 sends
+	// every update immediately
+	BroadcastInterval time.Duration
+
+	// ResultsBasisPoints adds an exact integer shareBps to each result
+	ResultsBasisPoints bool
+
+	// ExpectedVoters is the electorate size for /results/turnout; 0 disables it.
+	// QuorumThreshold is the fraction of ExpectedVoters needed for quorum.
+	ExpectedVoters  int
+	QuorumThreshold float64
+
+	// ResultsShuffle randomizes the /results order per response unless ?sort is given
+	ResultsShuffle bool
+
+	// ResultsDisplayCap shows counts above it as the cap with capped: true; 0 shows exact counts.
+	// Admins still see exact counts on /admin/results.
+	ResultsDisplayCap int
+
+	// Poll holds the identity rules for the default poll and the defaults for polls created later
+	Poll PollConfig
+
+	// AutoCreateCandidates turns votes for unknown names into write-in candidates
+	AutoCreateCandidates bool
+
+	// CandidateCreateRate limits write-in creations per second across all clients, allowing
+	// bursts of CandidateCreateBurst; 0 disables the limit
+	CandidateCreateRate  float64
+	CandidateCreateBurst int
+
+	// SyncVotes makes /vote wait for its vote to be counted and answer 200 with the new count,
+	// falling back to 202 after SyncVoteTimeout
+	SyncVotes       bool
+	SyncVoteTimeout time.Duration
+
+	// MaxPolls bounds how many polls, including the default one, can run at once
+	MaxPolls int
+
+	// VoteRate limits votes per second from each client IP, allowing bursts of VoteBurst;
+	// 0, the default, disables the limit. Behind a reverse proxy set TrustedProxies too, or
+	// every voter shares the proxy's address and so a single limit.
+	VoteRate  float64
+	VoteBurst int
+
+	// MaxVoteWeight caps the weight a single /vote may carry; 1 disables weighted ballots
+	MaxVoteWeight int
+
+	// TrustedProxies are the peers whose X-Forwarded-For header identifies the client
+	TrustedProxies []netip.Prefix
+
+	// CreateRequiresAdmin only lets requests carrying the admin token create write-ins
+	CreateRequiresAdmin bool
+
+	// SSEFieldOrder is the order id, event and data fields are written in each SSE event
+	SSEFieldOrder []string
+
+	// SSERetryMS is the reconnect delay, in milliseconds, sent as a retry hint when a stream
+	// opens; 0 leaves browsers on their default
+	SSERetryMS int
+
+	// TLSCertFile and TLSKeyFile, when both set, serve HTTPS instead of plain HTTP
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// PublicAddr, when set, serves read-only /results and /events on a second port
+	PublicAddr string
+
+	// AdminToken guards the admin routes; it is secret and never exposed by /config
+	AdminToken string
+
+	// CORS holds the allowed origins for every route
+	CORS CORSConfig
+
+	// SecurityHeaders are applied to every non-SSE response
+	SecurityHeaders map[string]string
+
+	// SSEHeaders are added to /events responses; the default stops nginx from buffering the stream
+	SSEHeaders map[string]string
+}
+
+// PublicConfig is the non-secret subset of Config returned by /config.
+// Fields are copied explicitly so new secrets are never exposed by default.
+type PublicConfig struct {
+	BufferSize          int       `json:"bufferSize"`
+	VoteBuffer          int       `json:"voteBuffer"`
+	ClientBuffer        int       `json:"clientBuffer"`
+	ListenAddr          string    `json:"listenAddr"`
+	PingInterval        string    `json:"pingInterval"`
+	SSEFlushDelay       string    `json:"sseFlushDelay"`
+	MaxHeaderBytes      int       `json:"maxHeaderBytes"`
+	ReadOnly            bool      `json:"readOnly"`
+	RequireVoterID      bool      `json:"requireVoterId"`
+	MaxPicks            int       `json:"maxPicks"`
+	OpenAt              time.Time `json:"openAt"`
+	Deadline            time.Time `json:"deadline"`
+	PreVoteMode         string    `json:"preVoteMode"`
+	PreVoteCap          int       `json:"preVoteCap"`
+	HistorySize         int       `json:"historySize"`
+	MaxCandidates       int       `json:"maxCandidates"`
+	MaxSSEClients       int       `json:"maxSseClients"`
+	LongPollTimeout     string    `json:"longPollTimeout"`
+	AutoCreate          bool      `json:"autoCreateCandidates"`
+	VoterMode           string    `json:"voterMode"`
+	CreateRate          float64   `json:"candidateCreateRate"`
+	CreateBurst         int       `json:"candidateCreateBurst"`
+	MaxPolls            int       `json:"maxPolls"`
+	SyncVotes           bool      `json:"syncVotes"`
+	SyncVoteTimeout     string    `json:"syncVoteTimeout"`
+	VoteRate            float64   `json:"voteRate"`
+	VoteBurst           int       `json:"voteBurst"`
+	MaxVoteWeight       int       `json:"maxVoteWeight"`
+	CreateRequiresAdmin bool      `json:"createRequiresAdmin"`
+	HandlerTimeout      string    `json:"handlerTimeout"`
+	DecayHalfLife       string    `json:"decayHalfLife"`
+	ShedThreshold       float64   `json:"shedThreshold"`
+	BasisPoints         bool      `json:"resultsBasisPoints"`
+	DisplayCap          int       `json:"resultsDisplayCap"`
+	Shuffle             bool      `json:"resultsShuffle"`
+	ExpectedVoters      int       `json:"expectedVoters"`
+	QuorumThreshold     float64   `json:"quorumThreshold"`
+	ExportEnabled       bool      `json:"exportEnabled"`
+	AuditEnabled        bool      `json:"auditEnabled"`
+	TracingEnabled      bool      `json:"tracingEnabled"`
+	ExportInterval      string    `json:"exportInterval"`
+	PublicAddr          string    `json:"publicAddr,omitempty"`
+	TLS                 bool      `json:"tls"`
+	SSEFieldOrder       []string  `json:"sseFieldOrder"`
+	SSERetryMS          int       `json:"sseRetryMs"`
+	CORSOrigins         []string  `json:"corsOrigins"`
+	CORSCredentials     bool      `json:"corsAllowCredentials"`
+	CORSMaxAge          string    `json:"corsMaxAge"`
+	MilestoneEvery      int       `json:"milestoneEvery"`
+	CandidateThrottle   string    `json:"candidateThrottle"`
+	BroadcastInterval   string    `json:"broadcastInterval"`
+	SSETokenRotation    string    `json:"sseTokenRotation"`
+	SSETokenGrace       string    `json:"sseTokenGrace"`
+}
+
+// LoadConfig reads the configuration from the environment, falling back to defaults
+func LoadConfig() Config {
+	return Config{
+		MaxHeaderBytes:        envInt("MAX_HEADER_BYTES", http.DefaultMaxHeaderBytes),
+		MinBuffer:             envInt("MIN_BUFFER", 16),
+		NumCPU:                runtime.NumCPU(),
+		VoteBuffer:            envInt("VOTE_BUFFER", 0),
+		ClientBuffer:          envInt("CLIENT_BUFFER", 0),
+		ReadOnly:              envBool("READ_ONLY", false),
+		Maintenance:           envBool("MAINTENANCE", false),
+		MaintenanceMessage:    cmp.Or(os.Getenv("MAINTENANCE_MESSAGE"), "The service is down for maintenance"),
+		MaintenanceRetryAfter: envDuration("MAINTENANCE_RETRY_AFTER", 5*time.Minute),
+		OpenAt:                envTime("OPEN_AT"),
+		Deadline:              envTime("VOTE_DEADLINE"),
+		PreVoteMode:           envChoice("PRE_VOTE_MODE", preVoteReject, preVoteReject, preVoteQueue),
+		PreVoteCap:            envInt("PRE_VOTE_CAP", 10000),
+		HistorySize:           envInt("HISTORY_SIZE", 100000),
+		SSEFlushDelay:         min(envDuration("SSE_FLUSH_DELAY", 0), maxSSEFlushDelay),
+		ListenAddr:            cmp.Or(os.Getenv("LISTEN_ADDR"), ":8080"),
+		PingInterval:          envDuration("SSE_PING_INTERVAL", time.Minute),
+		MaxCandidates:         envInt("MAX_CANDIDATES", 100),
+		CandidatesFile:        cmp.Or(os.Getenv("CANDIDATES_FILE"), "candidates.json"),
+		HandlerTimeout:        envDuration("HANDLER_TIMEOUT", 10*time.Second),
+		DecayHalfLife:         envDuration("DECAY_HALF_LIFE", 0),
+		ShedThreshold:         envFraction("SHED_THRESHOLD", 0),
+		MilestoneEvery:        envInt("MILESTONE_EVERY", 0),
+		CandidateThrottle:     envDuration("CANDIDATE_THROTTLE", 0),
+		BroadcastInterval:     envDuration("BROADCAST_INTERVAL", 0),
+		SSELogEvery:           envInt("SSE_LOG_EVERY", 100),
+		SlowClientMisses:      envInt("SLOW_CLIENT_MISSES", 3),
+		MaxSSEClients:         envInt("MAX_SSE_CLIENTS", 10000),
+		LongPollTimeout:       envDuration("LONG_POLL_TIMEOUT", 25*time.Second),
+		SSEReplayBuffer:       envInt("SSE_REPLAY_BUFFER", 256),
+		DataFile:              os.Getenv("DATA_FILE"),
+		SaveInterval:          envDuration("SAVE_INTERVAL", 5*time.Second),
+		AuditLogFile:          os.Getenv("AUDIT_LOG_FILE"),
+		AuditFlushInterval:    envDuration("AUDIT_FLUSH_INTERVAL", time.Second),
+		OTLPEndpoint:          os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		ExportURL:             os.Getenv("EXPORT_URL"),
+		ExportInterval:        envDuration("EXPORT_INTERVAL", 10*time.Second),
+		ExportToken:           os.Getenv("EXPORT_TOKEN"),
+		SSETokenRotation:      envDuration("SSE_TOKEN_ROTATION", 0),
+		SSETokenGrace:         envDuration("SSE_TOKEN_GRACE", 30*time.Second),
+		ResultsBasisPoints:    envBool("RESULTS_BASIS_POINTS", false),
+		ResultsDisplayCap:     envInt("RESULTS_DISPLAY_CAP", 0),
+		ResultsShuffle:        envBool("RESULTS_SHUFFLE", false),
+		ExpectedVoters:        envInt("EXPECTED_VOTERS", 0),
+		QuorumThreshold:       envFraction("QUORUM_THRESHOLD", 0.5),
+		AutoCreateCandidates:  envBool("AUTO_CREATE_CANDIDATES", false),
+		CandidateCreateRate:   envRate("CANDIDATE_CREATE_RATE", 1),
+		CandidateCreateBurst:  envInt("CANDIDATE_CREATE_BURST", 10),
+		CreateRequiresAdmin:   envBool("AUTO_CREATE_REQUIRES_ADMIN", false),
+		MaxPolls:              envInt("MAX_POLLS", 16),
+		SyncVotes:             envBool("SYNC_VOTES", false),
+		SyncVoteTimeout:       envDuration("SYNC_VOTE_TIMEOUT", 2*time.Second),
+		VoteRate:              envRate("VOTE_RATE", 0),
+		VoteBurst:             envInt("VOTE_BURST", 20),
+		MaxVoteWeight:         envInt("MAX_VOTE_WEIGHT", 1),
+		TrustedProxies:        envPrefixes("TRUSTED_PROXIES"),
+		Poll: PollConfig{
+			RequireVoterID: envBool("REQUIRE_VOTER_ID", false),
+			MaxPicks:       envInt("MAX_PICKS_PER_VOTER", 0),
+			VoterMode:      envChoice("VOTER_MODE", voterModeUnlimited, voterModeUnlimited, voterModeOnce, voterModeChange),
+		},
+		SSEFieldOrder: envFieldOrder("SSE_FIELD_ORDER", defaultSSEFieldOrder),
+		SSERetryMS:    envInt("SSE_RETRY_MS", 3000),
+		CORS: CORSConfig{
+			Origins:          envList("CORS_ORIGINS", []string{"*"}),
+			AllowCredentials: envBool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           envDuration("CORS_MAX_AGE", 10*time.Minute),
+		},
+		PublicAddr:  os.Getenv("PUBLIC_ADDR"),
+		TLSCertFile: os.Getenv("TLS_CERT_FILE"),
+		TLSKeyFile:  os.Getenv("TLS_KEY_FILE"),
+		AdminToken:  os.Getenv("ADMIN_TOKEN"),
+		SecurityHeaders: envHeaders("SECURITY_HEADERS", map[string]string{
+			"X-Content-Type-Options":  "nosniff",
+			"X-Frame-Options":         "DENY",
+			"Content-Security-Policy": "default-src 'none'; frame-ancestors 'none'",
+		}),
+		SSEHeaders: envHeaders("SSE_HEADERS", map[string]string{
+			"X-Accel-Buffering": "no",
+		}),
+	}
+}
+
+// TLSEnabled reports whether the servers speak HTTPS
+func (c Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// bufferSize returns the channel buffer size, scaled by CPU count but never below MinBuffer
+func (c Config) bufferSize() int {
+	return max(c.NumCPU*2, c.MinBuffer)
+}
+
+// voteBufferSize returns VoteBuffer, or bufferSize when it is unset
+func (c Config) voteBufferSize() int {
+	if c.VoteBuffer > 0 {
+		return c.VoteBuffer
+	}
+	return c.bufferSize()
+}
+
+// clientBufferSize returns ClientBuffer, or bufferSize when it is unset
+func (c Config) clientBufferSize() int {
+	if c.ClientBuffer > 0 {
+		return c.ClientBuffer
+	}
+	return c.bufferSize()
+}
+
+// public returns the configuration that is safe to show to clients
+func (c Config) public() PublicConfig {
+	return PublicConfig{
+		BufferSize:          c.bufferSize(),
+		VoteBuffer:          c.voteBufferSize(),
+		ClientBuffer:        c.clientBufferSize(),
+		ListenAddr:          c.ListenAddr,
+		PingInterval:        c.PingInterval.String(),
+		SSEFlushDelay:       c.SSEFlushDelay.String(),
+		MaxHeaderBytes:      c.MaxHeaderBytes,
+		ReadOnly:            c.ReadOnly,
+		RequireVoterID:      c.Poll.RequireVoterID,
+		MaxPicks:            c.Poll.MaxPicks,
+		OpenAt:              c.OpenAt,
+		Deadline:            c.Deadline,
+		PreVoteMode:         c.PreVoteMode,
+		PreVoteCap:          c.PreVoteCap,
+		HistorySize:         c.HistorySize,
+		MaxCandidates:       c.MaxCandidates,
+		MaxSSEClients:       c.MaxSSEClients,
+		LongPollTimeout:     c.LongPollTimeout.String(),
+		AutoCreate:          c.AutoCreateCandidates,
+		VoterMode:           c.Poll.VoterMode,
+		CreateRate:          c.CandidateCreateRate,
+		CreateBurst:         c.CandidateCreateBurst,
+		MaxPolls:            c.MaxPolls,
+		SyncVotes:           c.SyncVotes,
+		SyncVoteTimeout:     c.SyncVoteTimeout.String(),
+		VoteRate:            c.VoteRate,
+		VoteBurst:           c.VoteBurst,
+		MaxVoteWeight:       c.MaxVoteWeight,
+		CreateRequiresAdmin: c.CreateRequiresAdmin,
+		HandlerTimeout:      c.HandlerTimeout.String(),
+		DecayHalfLife:       c.DecayHalfLife.String(),
+		ShedThreshold:       c.ShedThreshold,
+		BasisPoints:         c.ResultsBasisPoints,
+		DisplayCap:          c.ResultsDisplayCap,
+		Shuffle:             c.ResultsShuffle,
+		ExpectedVoters:      c.ExpectedVoters,
+		QuorumThreshold:     c.QuorumThreshold,
+		ExportEnabled:       c.ExportURL != "",
+		AuditEnabled:        c.AuditLogFile != "",
+		TracingEnabled:      c.OTLPEndpoint != "",
+		ExportInterval:      c.ExportInterval.String(),
+		PublicAddr:          c.PublicAddr,
+		TLS:                 c.TLSEnabled(),
+		SSEFieldOrder:       c.SSEFieldOrder,
+		SSERetryMS:          c.SSERetryMS,
+		CORSOrigins:         c.CORS.Origins,
+		CORSCredentials:     c.CORS.AllowCredentials,
+		CORSMaxAge:          c.CORS.MaxAge.String(),
+		MilestoneEvery:      c.MilestoneEvery,
+		CandidateThrottle:   c.CandidateThrottle.String(),
+		BroadcastInterval:   c.BroadcastInterval.String(),
+		SSETokenRotation:    c.SSETokenRotation.String(),
+		SSETokenGrace:       c.SSETokenGrace.String(),
+	}
+}
+
+// configHandler returns the effective non-secret configuration
+func (vm *VoteManager) configHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, vm.cfg.public())
+}
+
+// envBool reads a boolean from the environment or returns def
+func envBool(key string, def bool) bool {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		slog.Warn("Invalid environment value, using default", "key", key, "value", raw, "default", def)
+		return def
+	}
+	return v
+}
+
+// envTime reads an RFC3339 timestamp from the environment, returning the zero time when unset or invalid
+func envTime(key string) time.Time {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		slog.Warn("Invalid environment value, expected RFC3339", "key", key, "value", raw, "error", err)
+		return time.Time{}
+	}
+	return t
+}
+
+// envChoice reads one of the allowed values from the environment or returns def
+func envChoice(key, def string, allowed ...string) string {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	if !slices.Contains(allowed, raw) {
+		slog.Warn("Invalid environment value, using default", "key", key, "value", raw, "default", def)
+		return def
+	}
+	return raw
+}
+
+// envHeaders reads a JSON object of header names to values from the environment or returns def.
+// Set it to {} to send no extra headers.
+func envHeaders(key string, def map[string]string) map[string]string {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(raw), &headers); err != nil {
+		slog.Warn("Invalid environment value, using defaults", "key", key, "value", raw, "error", err)
+		return def
+	}
+	return headers
+}
+
+// envDuration reads a Go duration from the environment or returns def
+func envDuration(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d < 0 {
+		slog.Warn("Invalid environment value, using default", "key", key, "value", raw, "default", def.String())
+		return def
+	}
+	return d
+}
+
+// envFraction reads a number in [0, 1] from the environment or returns def
+func envFraction(key string, def float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 || v > 1 {
+		slog.Warn("Invalid environment value, using default", "key", key, "value", raw, "default", def)
+		return def
+	}
+	return v
+}
+
+// envRate reads a non-negative number from the environment or returns def
+func envRate(key string, def float64) float64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		slog.Warn("Invalid environment value, using default", "key", key, "value", raw, "default", def)
+		return def
+	}
+	return v
+}
+
+// envList reads a comma-separated list from the environment or returns def
+func envList(key string, def []string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	if len(list) == 0 {
+		return def
+	}
+	return list
+}
+
+// envPrefixes reads a comma-separated list of IPs or CIDR prefixes from the environment,
+// skipping and logging invalid entries
+func envPrefixes(key string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, raw := range strings.Split(os.Getenv(key), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		p, err := netip.ParsePrefix(raw)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(raw)
+			if addrErr != nil {
+				slog.Warn("Invalid environment list entry, skipping", "key", key, "value", raw, "error", err)
+				continue
+			}
+			p = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes
+}
+
+// envFieldOrder reads a comma-separated ordering of the SSE fields id, event and data,
+// returning def unless it names each of them exactly once
+func envFieldOrder(key string, def []string) []string {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	order := strings.Split(raw, ",")
+	for i := range order {
+		order[i] = strings.TrimSpace(order[i])
+	}
+	if !slices.Equal(slices.Sorted(slices.Values(order)), slices.Sorted(slices.Values(def))) {
+		slog.Warn("Invalid environment value, using default", "key", key, "value", raw, "default", strings.Join(def, ","))
+		return def
+	}
+	return order
+}
+
+// envInt reads a non-negative integer from the environment or returns def
+func envInt(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		slog.Warn("Invalid environment value, using default", "key", key, "value", raw, "default", def)
+		return def
+	}
+	return v
+}
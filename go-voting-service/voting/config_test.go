@@ -0,0 +1,45 @@
+package voting
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBufferSizeFloorOnSingleCPU(t *testing.T) {
+	cfg := testConfig()
+	cfg.NumCPU, cfg.MinBuffer = 1, 16
+	if got := cfg.bufferSize(); got != 16 {
+		t.Errorf("bufferSize with one CPU = %d, want the floor 16", got)
+	}
+	vm := newManager(t, cfg)
+	if got := cap(vm.voteChannel); got != 16 {
+		t.Errorf("vote channel capacity %d, want 16", got)
+	}
+
+	cfg.NumCPU = 32
+	if got := cfg.bufferSize(); got != 64 {
+		t.Errorf("bufferSize with 32 CPUs = %d, want 64", got)
+	}
+}
+
+func TestConfigEndpointIsSanitized(t *testing.T) {
+	cfg := testConfig()
+	cfg.ExportToken = "export-secret"
+	rec := serve(startManager(t, cfg).Handler(), http.MethodGet, "/config", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+	}
+	var fields map[string]any
+	decodeJSON(t, rec, &fields)
+	for _, name := range []string{"bufferSize", "pingInterval"} {
+		if _, ok := fields[name]; !ok {
+			t.Errorf("no %s in %s", name, rec.Body)
+		}
+	}
+	for _, secret := range []string{testAdminToken, "export-secret"} {
+		if strings.Contains(rec.Body.String(), secret) {
+			t.Errorf("/config leaks a secret: %s", rec.Body)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package voting
+
+import (
+	"bytes"
+	"encoding/csv"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// resultsCSVHandler returns the current results as a CSV download
+func (vm *VoteManager) resultsCSVHandler(w http.ResponseWriter, r *http.Request) {
+	payload, err := vm.requestedResults(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeResultsCSV(w, payload)
+}
+
+// writeResultsCSV writes payload as CSV with a name,votes,percentage header row. encoding/csv
+// quotes names containing commas or quotes. Like writeJSON it buffers first, so a failure
+// yields a clean 500.
+func writeResultsCSV(w http.ResponseWriter, payload ResultsPayload) {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	cw.Write([]string{"name", "votes", "percentage"})
+	for _, c := range payload.Candidates {
+		cw.Write([]string{c.Name, strconv.Itoa(c.Votes), strconv.FormatFloat(c.Percentage, 'f', 2, 64)})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		slog.Error("Failed to encode CSV", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="results.csv"`)
+	w.Write(buf.Bytes())
+}
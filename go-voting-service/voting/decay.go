@@ -0,0 +1,33 @@
+package voting
+
+import (
+	"math"
+	"time"
+)
+
+// decay tracks an exponentially decaying vote count. The stored value is only
+// brought forward to the current time when a vote is added or a reader asks for it.
+type decay struct {
+	value float64
+	at    time.Time
+}
+
+// valueAt returns the decayed count at now for the given half-life
+func (d decay) valueAt(now time.Time, halfLife time.Duration) float64 {
+	if d.at.IsZero() {
+		return 0
+	}
+	elapsed := now.Sub(d.at).Seconds()
+	return d.value * math.Exp2(-elapsed/halfLife.Seconds())
+}
+
+// add decays the current value to now and adds weight
+func (d *decay) add(now time.Time, halfLife time.Duration, weight float64) {
+	d.value = d.valueAt(now, halfLife) + weight
+	d.at = now
+}
+
+// roundDecayed trims a decayed count to three decimals for display
+func roundDecayed(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,47 @@
+package voting
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDecayedVotesHalveEachHalfLife(t *testing.T) {
+	clock := newFakeClock()
+	cfg := testConfig()
+	cfg.DecayHalfLife = time.Hour
+	vm := startManagerWithClock(t, cfg, clock)
+	h := vm.Handler()
+
+	for range 8 {
+		if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "votes", func() bool { return votesFor(vm, "Candidate A") == 8 })
+
+	candidateA := func() *Candidate {
+		var results ResultsPayload
+		decodeJSON(t, serve(h, http.MethodGet, "/results", ""), &results)
+		for _, c := range results.Candidates {
+			if c.Name == "Candidate A" {
+				return c
+			}
+		}
+		t.Fatal("Candidate A missing")
+		return nil
+	}
+	for _, want := range []float64{8, 4, 2, 1} {
+		a := candidateA()
+		if a.DecayedVotes == nil {
+			t.Fatal("no decayed votes with a half-life set")
+		}
+		if *a.DecayedVotes != want {
+			t.Errorf("decayed votes %v after %s, want %v", *a.DecayedVotes, clock.Now().Sub(newFakeClock().Now()), want)
+		}
+		if a.Votes != 8 {
+			t.Errorf("raw votes %d, want 8", a.Votes)
+		}
+		clock.Advance(time.Hour)
+	}
+}
@@ -0,0 +1,27 @@
+package voting
+
+import "net/http"
+
+// capped returns the count to show publicly for votes and whether it was capped
+func capped(votes, limit int) (int, bool) {
+	if limit > 0 && votes > limit {
+		return limit, true
+	}
+	return votes, false
+}
+
+// applyDisplayCap clamps each candidate's votes to limit, marking the clamped ones. A clamped
+// candidate's rate and decayed count are dropped too, as either would reveal votes past the cap.
+func applyDisplayCap(candidates []*Candidate, limit int) {
+	for _, c := range candidates {
+		c.Votes, c.Capped = capped(c.Votes, limit)
+		if c.Capped {
+			c.Rate, c.DecayedVotes = 0, nil
+		}
+	}
+}
+
+// exactResultsHandler returns the uncapped results for admins
+func (vm *VoteManager) exactResultsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, newResultsPayload(vm.exactResults()))
+}
@@ -0,0 +1,74 @@
+package voting
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestResultsDisplayCap(t *testing.T) {
+	cfg := testConfig()
+	cfg.ResultsDisplayCap = 2
+	cfg.ExpectedVoters = 10
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+
+	for range 3 {
+		if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "votes", func() bool { return votesFor(vm, "Candidate A") == 3 })
+
+	find := func(candidates []*Candidate) *Candidate {
+		for _, c := range candidates {
+			if c.Name == "Candidate A" {
+				return c
+			}
+		}
+		t.Fatal("Candidate A missing")
+		return nil
+	}
+
+	var public ResultsPayload
+	decodeJSON(t, serve(h, http.MethodGet, "/results", ""), &public)
+	if a := find(public.Candidates); a.Votes != 2 || !a.Capped || a.Rate != 0 || public.Total != 2 {
+		t.Errorf("public results: votes %d, capped %v, rate %d, total %d; want 2, true, 0, 2", a.Votes, a.Capped, a.Rate, public.Total)
+	}
+
+	var exact ResultsPayload
+	decodeJSON(t, serve(h, http.MethodGet, "/admin/results", "", adminHeader...), &exact)
+	if a := find(exact.Candidates); a.Votes != 3 || a.Capped {
+		t.Errorf("admin results: votes %d, capped %v; want 3, false", a.Votes, a.Capped)
+	}
+
+	var stats Stats
+	decodeJSON(t, serve(h, http.MethodGet, "/stats", ""), &stats)
+	if stats.TotalVotes != 2 {
+		t.Errorf("/stats totalVotes %d, want 2", stats.TotalVotes)
+	}
+
+	var turnout Turnout
+	decodeJSON(t, serve(h, http.MethodGet, "/results/turnout", ""), &turnout)
+	if turnout.TotalVotes != 2 || turnout.TurnoutPercent != 20 {
+		t.Errorf("/results/turnout total %d, percent %v; want 2, 20", turnout.TotalVotes, turnout.TurnoutPercent)
+	}
+
+	now := time.Now()
+	query := url.Values{
+		"from": {now.Add(-time.Hour).Format(time.RFC3339)},
+		"to":   {now.Add(time.Hour).Format(time.RFC3339)},
+	}
+	var rng RangeResults
+	decodeJSON(t, serve(h, http.MethodGet, "/results/range?"+query.Encode(), ""), &rng)
+	if a := find(rng.Candidates); a.Votes != 2 || !a.Capped {
+		t.Errorf("/results/range votes %d, capped %v; want 2, true", a.Votes, a.Capped)
+	}
+
+	var rates VoteRates
+	decodeJSON(t, serve(h, http.MethodGet, "/rates", ""), &rates)
+	if rate, ok := rates.Rates["Candidate A"]; ok {
+		t.Errorf("/rates reports %d for a capped candidate", rate)
+	}
+}
@@ -0,0 +1,63 @@
+package voting_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go-voting-service/voting"
+)
+
+// TestEmbedUnderPathPrefix drives the package only through its exported API, the way another
+// server would embed it
+func TestEmbedUnderPathPrefix(t *testing.T) {
+	cfg := voting.LoadConfig()
+	cfg.CandidatesFile = ""
+	vm, err := voting.NewVoteManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	vm.Start(ctx)
+	defer vm.Stop()
+
+	mux := http.NewServeMux()
+	mux.Handle("/poll/", http.StripPrefix("/poll", vm.Handler()))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/poll/vote", "application/json", strings.NewReader(`{"candidate":"Candidate A"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("vote: status %d, want 202", resp.StatusCode)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, err := http.Get(ts.URL + "/poll/results")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var results voting.ResultsPayload
+		err = json.NewDecoder(resp.Body).Decode(&results)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if results.Total == 1 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("total %d, want 1", results.Total)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,39 @@
+package voting
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestErrorResponsesAreJSON(t *testing.T) {
+	h := startManager(t, testConfig()).Handler()
+	for _, tc := range []struct {
+		method, target, body string
+		status               int
+	}{
+		{http.MethodPost, "/vote", `{"candidate":`, http.StatusBadRequest},
+		{http.MethodPost, "/vote", `{"candidate":""}`, http.StatusBadRequest},
+		{http.MethodPost, "/vote", `{"candidate":"Nobody"}`, http.StatusNotFound},
+		{http.MethodPut, "/vote", "", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/no-such-route", "", http.StatusNotFound},
+	} {
+		rec := serve(h, tc.method, tc.target, tc.body)
+		if rec.Code != tc.status {
+			t.Errorf("%s %s %s: status %d, want %d", tc.method, tc.target, tc.body, rec.Code, tc.status)
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("%s %s: Content-Type %q, want application/json", tc.method, tc.target, ct)
+		}
+		var body ErrorResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Errorf("%s %s: invalid JSON %q: %v", tc.method, tc.target, rec.Body, err)
+			continue
+		}
+		if body.Status != tc.status || body.Error == "" {
+			t.Errorf("%s %s: body %s, want an error message and status %d", tc.method, tc.target, rec.Body, tc.status)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package voting
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+)
+
+// etagResponse tags successful responses with a weak ETag hashed from the body and answers 304
+// Not Modified when If-None-Match already names it. Every vote, candidate change and reset
+// changes the body, so the tag changes with it. It buffers the response, so it must never wrap
+// the SSE stream.
+func etagResponse(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rec := &bufferedResponse{header: w.Header(), status: http.StatusOK}
+		next(rec, r)
+		if rec.status != http.StatusOK {
+			w.WriteHeader(rec.status)
+			w.Write(rec.body.Bytes())
+			return
+		}
+
+		h := fnv.New64a()
+		h.Write(rec.body.Bytes())
+		etag := fmt.Sprintf(`W/"%x"`, h.Sum64())
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			// A 304 carries no body, so drop the headers describing one
+			w.Header().Del("Content-Type")
+			w.Header().Del("Content-Disposition")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(rec.status)
+		w.Write(rec.body.Bytes())
+	}
+}
+
+// etagMatches reports whether an If-None-Match header names etag, using the weak comparison
+// RFC 9110 prescribes for it
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,67 @@
+package voting
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// exportMeasurement is the InfluxDB measurement candidate counts are written to
+const exportMeasurement = "votes"
+
+// tagEscaper escapes the characters InfluxDB line protocol reserves in tag values
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// lineProtocol encodes one point per candidate, tagged with the candidate name
+func lineProtocol(candidates []*Candidate, at time.Time) []byte {
+	var buf bytes.Buffer
+	for _, c := range candidates {
+		fmt.Fprintf(&buf, "%s,candidate=%s votes=%di %d\n", exportMeasurement, tagEscaper.Replace(c.Name), c.Votes, at.UnixNano())
+	}
+	return buf.Bytes()
+}
+
+// runExporter pushes the exact counts to ExportURL every ExportInterval until ctx is done or the
+// manager stops. It runs on its own goroutine, so a slow or failing sink never holds up voting;
+// a failed push is logged and the next interval sends the then-current counts again.
+func (vm *VoteManager) runExporter(ctx context.Context) {
+	client := &http.Client{Timeout: vm.cfg.ExportInterval}
+	for {
+		select {
+		case <-vm.clock.After(vm.cfg.ExportInterval):
+		case <-ctx.Done():
+			return
+		case <-vm.done:
+			return
+		}
+		if err := vm.export(ctx, client); err != nil {
+			slog.Warn("Failed to export results", "error", err)
+		}
+	}
+}
+
+// export posts one batch of points to the sink
+func (vm *VoteManager) export(ctx context.Context, client *http.Client) error {
+	body := lineProtocol(vm.exactResults(), vm.clock.Now())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, vm.cfg.ExportURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if vm.cfg.ExportToken != "" {
+		req.Header.Set("Authorization", "Token "+vm.cfg.ExportToken)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sink returned %s", resp.Status)
+	}
+	return nil
+}
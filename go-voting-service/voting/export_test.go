@@ -0,0 +1,65 @@
+package voting
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExporterPostsLineProtocolOnInterval(t *testing.T) {
+	type push struct {
+		auth string
+		body string
+	}
+	pushes := make(chan push, 4)
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		pushes <- push{auth: r.Header.Get("Authorization"), body: string(body)}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer sink.Close()
+
+	clock := newFakeClock()
+	cfg := testConfig()
+	cfg.ExportURL = sink.URL
+	cfg.ExportInterval = 10 * time.Second
+	cfg.ExportToken = "sink-token"
+	vm := startManagerWithClock(t, cfg, clock)
+
+	for range 2 {
+		if rec := postVote(vm.Handler(), "Candidate A"); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "votes", func() bool { return votesFor(vm, "Candidate A") == 2 })
+	waitFor(t, "the export timer", func() bool { return clock.Pending() > 0 })
+
+	select {
+	case p := <-pushes:
+		t.Fatalf("pushed before the interval elapsed: %q", p.body)
+	default:
+	}
+	clock.Advance(cfg.ExportInterval)
+
+	var p push
+	select {
+	case p = <-pushes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no push after the interval")
+	}
+	if p.auth != "Token sink-token" {
+		t.Errorf("Authorization %q, want the export token", p.auth)
+	}
+	ts := clock.Now().UnixNano()
+	want := []string{
+		`votes,candidate=Candidate\ A votes=2i ` + strconv.FormatInt(ts, 10),
+		`votes,candidate=Candidate\ B votes=0i ` + strconv.FormatInt(ts, 10),
+	}
+	if got := strings.Split(strings.TrimSpace(p.body), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("pushed\n%s\nwant\n%s", p.body, strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,58 @@
+package voting
+
+import (
+	"bytes"
+	"compress/gzip"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// gzipMinBytes is the smallest response worth compressing; below it gzip's overhead outweighs the savings
+const gzipMinBytes = 1 << 10
+
+// bufferedResponse captures a handler's response so it can be compressed as a whole
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+func (b *bufferedResponse) WriteHeader(status int) { b.status = status }
+
+// gzipResponse compresses responses of at least gzipMinBytes for clients accepting gzip.
+// It buffers the whole response, so it must never wrap the SSE stream.
+func gzipResponse(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsEncoding(r.Header.Get("Accept-Encoding"), "gzip") {
+			next(w, r)
+			return
+		}
+
+		rec := &bufferedResponse{header: w.Header(), status: http.StatusOK}
+		next(rec, r)
+		body := rec.body.Bytes()
+		if len(body) >= gzipMinBytes && w.Header().Get("Content-Encoding") == "" {
+			var compressed bytes.Buffer
+			zw := gzip.NewWriter(&compressed)
+			_, err := zw.Write(body)
+			if err == nil {
+				err = zw.Close()
+			}
+			if err != nil {
+				slog.Error("Failed to gzip response", "error", err)
+			} else {
+				body = compressed.Bytes()
+				w.Header().Set("Content-Encoding", "gzip")
+			}
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(rec.status)
+		w.Write(body)
+	}
+}
@@ -0,0 +1,49 @@
+package voting
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestResultsGzip(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+
+	// Two candidates stay under the threshold
+	if rec := serve(h, http.MethodGet, "/results", "", "Accept-Encoding", "gzip"); rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("small response compressed (%d bytes)", rec.Body.Len())
+	}
+
+	for i := range 40 {
+		if err := vm.AddCandidate(fmt.Sprintf("Candidate %02d", i), CandidateInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plain := serve(h, http.MethodGet, "/results", "")
+	if plain.Header().Get("Content-Encoding") != "" {
+		t.Fatal("response compressed without Accept-Encoding")
+	}
+	if plain.Body.Len() < gzipMinBytes {
+		t.Fatalf("results are %d bytes, too small to exercise gzip", plain.Body.Len())
+	}
+
+	rec := serve(h, http.MethodGet, "/results", "", "Accept-Encoding", "gzip")
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding %q, want gzip", got)
+	}
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, plain.Body.Bytes()) {
+		t.Errorf("decompressed body differs from the plain one:\n%s\nwant\n%s", body, plain.Body)
+	}
+}
@@ -0,0 +1,88 @@
+package voting
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// voteRecord is a single counted vote kept for time-range queries
+type voteRecord struct {
+	at        time.Time
+	candidate string
+	weight    int
+}
+
+// voteHistory is a bounded, chronological log of cast votes. Unvotes and changed votes are
+// not recorded, so a window's counts are the votes cast in it and never go negative.
+type voteHistory struct {
+	mu      sync.RWMutex
+	max     int
+	records []voteRecord
+}
+
+// add appends a vote, dropping the oldest records beyond the configured size
+func (h *voteHistory) add(at time.Time, candidate string, weight int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, voteRecord{at: at, candidate: candidate, weight: weight})
+	if len(h.records) > h.max {
+		h.records = h.records[len(h.records)-h.max:]
+	}
+}
+
+// reset drops every record
+func (h *voteHistory) reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = nil
+}
+
+// countBetween returns per-candidate vote counts cast in [from, to)
+func (h *voteHistory) countBetween(from, to time.Time) []*Candidate {
+	h.mu.RLock()
+	counts := make(map[string]int)
+	for _, rec := range h.records {
+		if !rec.at.Before(from) && rec.at.Before(to) {
+			counts[rec.candidate] += rec.weight
+		}
+	}
+	h.mu.RUnlock()
+
+	candidateList := make([]*Candidate, 0, len(counts))
+	for name, votes := range counts {
+		candidateList = append(candidateList, &Candidate{Name: name, Votes: votes})
+	}
+	sortCandidates(candidateList, sortByName)
+	return candidateList
+}
+
+// RangeResults is the payload returned by /results/range
+type RangeResults struct {
+	From       time.Time    `json:"from"`
+	To         time.Time    `json:"to"`
+	Candidates []*Candidate `json:"candidates"`
+}
+
+// rangeResultsHandler returns per-candidate counts of votes cast between ?from and ?to (RFC3339),
+// capped at RESULTS_DISPLAY_CAP like /results
+func (vm *VoteManager) rangeResultsHandler(w http.ResponseWriter, r *http.Request) {
+	from, err := time.Parse(time.RFC3339, r.URL.Query().Get("from"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "from must be an RFC3339 timestamp")
+		return
+	}
+	to, err := time.Parse(time.RFC3339, r.URL.Query().Get("to"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "to must be an RFC3339 timestamp")
+		return
+	}
+	if !from.Before(to) {
+		writeJSONError(w, http.StatusBadRequest, "from must be before to")
+		return
+	}
+
+	results := RangeResults{From: from, To: to, Candidates: vm.history.countBetween(from, to)}
+	applyDisplayCap(results.Candidates, vm.cfg.ResultsDisplayCap)
+	writeJSON(w, http.StatusOK, results)
+}
@@ -0,0 +1,118 @@
+package voting
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestRangeResultsCountOnlyVotesInWindow(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	vm := startManagerWithClock(t, testConfig(), clock)
+	h := vm.Handler()
+
+	// Candidate A at start and start+20m, Candidate B twice at start+10m
+	cast := func(candidate string, n int) {
+		t.Helper()
+		want := votesFor(vm, candidate) + n
+		for range n {
+			if rec := postVote(h, candidate); rec.Code != http.StatusAccepted {
+				t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+			}
+		}
+		waitFor(t, "votes for "+candidate, func() bool { return votesFor(vm, candidate) == want })
+	}
+	cast("Candidate A", 1)
+	clock.Advance(10 * time.Minute)
+	cast("Candidate B", 2)
+	clock.Advance(10 * time.Minute)
+	cast("Candidate A", 1)
+
+	query := url.Values{
+		"from": {start.Add(5 * time.Minute).Format(time.RFC3339)},
+		"to":   {start.Add(15 * time.Minute).Format(time.RFC3339)},
+	}
+	rec := serve(h, http.MethodGet, "/results/range?"+query.Encode(), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+	}
+	var results RangeResults
+	decodeJSON(t, rec, &results)
+	if len(results.Candidates) != 1 || results.Candidates[0].Name != "Candidate B" || results.Candidates[0].Votes != 2 {
+		t.Errorf("range results %+v, want only Candidate B with 2", results.Candidates)
+	}
+
+	// The window's end is exclusive
+	query.Set("from", start.Format(time.RFC3339))
+	query.Set("to", start.Add(20*time.Minute).Format(time.RFC3339))
+	decodeJSON(t, serve(h, http.MethodGet, "/results/range?"+query.Encode(), ""), &results)
+	for _, c := range results.Candidates {
+		if c.Name == "Candidate A" && c.Votes != 1 {
+			t.Errorf("Candidate A has %d votes in [start, start+20m), want 1", c.Votes)
+		}
+	}
+}
+
+func TestRangeResultsRejectBadWindow(t *testing.T) {
+	h := startManager(t, testConfig()).Handler()
+	for _, query := range []string{
+		"from=yesterday&to=2024-01-01T00:00:00Z",
+		"from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z",
+	} {
+		if rec := serve(h, http.MethodGet, "/results/range?"+query, ""); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestRangeResultsIgnoreRetractions(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	cfg := testConfig()
+	cfg.Poll.VoterMode = voterModeChange
+	vm := startManagerWithClock(t, cfg, clock)
+	h := vm.Handler()
+
+	for _, body := range []string{
+		`{"candidate":"Candidate A","voter":"alice"}`,
+		`{"candidate":"Candidate A","voter":"bob"}`,
+	} {
+		if rec := serve(h, http.MethodPost, "/vote", body); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote %s: status %d, body %s", body, rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "the first votes", func() bool { return votesFor(vm, "Candidate A") == 2 })
+
+	// Ten minutes later alice moves to B and an admin removes bob's vote
+	clock.Advance(10 * time.Minute)
+	if rec := serve(h, http.MethodPost, "/vote", `{"candidate":"Candidate B","voter":"alice"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("changed vote: status %d, body %s", rec.Code, rec.Body)
+	}
+	waitFor(t, "the changed vote", func() bool { return votesFor(vm, "Candidate B") == 1 })
+	if rec := serve(h, http.MethodPost, "/unvote?candidate=Candidate+A", "", adminHeader...); rec.Code != http.StatusOK {
+		t.Fatalf("unvote: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	rangeCounts := func(from, to time.Duration) map[string]int {
+		t.Helper()
+		query := url.Values{
+			"from": {start.Add(from).Format(time.RFC3339)},
+			"to":   {start.Add(to).Format(time.RFC3339)},
+		}
+		var results RangeResults
+		decodeJSON(t, serve(h, http.MethodGet, "/results/range?"+query.Encode(), ""), &results)
+		counts := make(map[string]int)
+		for _, c := range results.Candidates {
+			counts[c.Name] = c.Votes
+		}
+		return counts
+	}
+	if got := rangeCounts(5*time.Minute, 15*time.Minute); len(got) != 1 || got["Candidate B"] != 1 {
+		t.Errorf("window holding the change and the unvote counts %v, want only Candidate B's cast vote", got)
+	}
+	if got := rangeCounts(0, 15*time.Minute); got["Candidate A"] != 2 || got["Candidate B"] != 1 {
+		t.Errorf("whole window counts %v, want every cast vote: A 2, B 1", got)
+	}
+}
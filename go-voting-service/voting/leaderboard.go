@@ -0,0 +1,39 @@
+package voting
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// defaultLeaderboardSize is how many candidates /leaderboard returns without a valid ?n
+const defaultLeaderboardSize = 3
+
+// Leaderboard is the payload returned by /leaderboard
+type Leaderboard struct {
+	// Leader is the candidate with the most votes, or nil while several share the top spot
+	Leader     *string      `json:"leader"`
+	Candidates []*Candidate `json:"candidates"`
+}
+
+// leaderboard returns the top n candidates by votes, ties broken by name
+func (vm *VoteManager) leaderboard(n int) Leaderboard {
+	candidates := vm.results()
+	sortCandidates(candidates, sortByVotesDesc)
+
+	var board Leaderboard
+	if len(candidates) == 1 || len(candidates) > 1 && candidates[0].Votes > candidates[1].Votes {
+		board.Leader = &candidates[0].Name
+	}
+	board.Candidates = candidates[:min(n, len(candidates))]
+	return board
+}
+
+// leaderboardHandler returns the top ?n candidates, defaulting to defaultLeaderboardSize when
+// n is missing or not a positive number, so a display need not fetch every candidate
+func (vm *VoteManager) leaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	n, err := strconv.Atoi(r.URL.Query().Get("n"))
+	if err != nil || n < 1 {
+		n = defaultLeaderboardSize
+	}
+	writeJSON(w, http.StatusOK, vm.leaderboard(n))
+}
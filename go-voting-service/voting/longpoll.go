@@ -0,0 +1,79 @@
+package voting
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// LongPollResponse is the body of /results/poll: the results and the sequence ID to pass as
+// ?since on the next request
+type LongPollResponse struct {
+	ID      uint64         `json:"id"`
+	Results ResultsPayload `json:"results"`
+}
+
+// changeNotifier wakes long-poll waiters whenever the broadcast sequence advances
+type changeNotifier struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// wait returns a channel closed by the next notify
+func (n *changeNotifier) wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ch == nil {
+		n.ch = make(chan struct{})
+	}
+	return n.ch
+}
+
+// notify wakes every current waiter
+func (n *changeNotifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ch != nil {
+		close(n.ch)
+		n.ch = nil
+	}
+}
+
+// longPollHandler is the fallback for clients behind proxies that buffer SSE. It answers as soon
+// as the sequence passes ?since, which shares its IDs with the stream, and with 204 after
+// LongPollTimeout so the client simply asks again. Without ?since it answers right away.
+func (vm *VoteManager) longPollHandler(w http.ResponseWriter, r *http.Request) {
+	raw := r.URL.Query().Get("since")
+	var since uint64
+	if raw != "" {
+		var err error
+		if since, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "since must be a sequence ID")
+			return
+		}
+	}
+
+	timeout := vm.clock.After(vm.cfg.LongPollTimeout)
+	for {
+		// Take the wait channel before reading the sequence so no change is missed in between
+		changed := vm.changes.wait()
+		// Read the ID first: the snapshot may already include later changes, but never lacks one
+		if id := vm.seq.Load(); id > since || raw == "" {
+			payload, err := vm.requestedResults(r)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			writeJSON(w, http.StatusOK, LongPollResponse{ID: id, Results: payload})
+			return
+		}
+		select {
+		case <-changed:
+		case <-timeout:
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
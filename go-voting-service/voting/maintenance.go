@@ -0,0 +1,26 @@
+package voting
+
+import "net/http"
+
+// MaintenanceResponse is the body every endpoint but /healthz returns in maintenance mode
+type MaintenanceResponse struct {
+	Error   string `json:"error"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// healthzHandler reports that the process is up; it stays 200 even in maintenance mode
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// MaintenanceHandler answers every request with 503 except the /healthz liveness probe
+func MaintenanceHandler(cfg Config) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.Handle("/", corsMiddleware(cfg.CORS, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", retryAfter(cfg.MaintenanceRetryAfter))
+		writeJSON(w, http.StatusServiceUnavailable, MaintenanceResponse{Error: "maintenance", Status: http.StatusServiceUnavailable, Message: cfg.MaintenanceMessage})
+	})))
+	return mux
+}
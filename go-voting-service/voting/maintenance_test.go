@@ -0,0 +1,34 @@
+package voting
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMaintenanceModeAnswers503ExceptHealthz(t *testing.T) {
+	cfg := testConfig()
+	cfg.Maintenance = true
+	h := newManager(t, cfg).Handler()
+
+	for _, tt := range []struct{ method, target, body string }{
+		{http.MethodPost, "/vote", `{"candidate":"Candidate A"}`},
+		{http.MethodGet, "/results", ""},
+	} {
+		rec := serve(h, tt.method, tt.target, tt.body)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: status %d, want 503", tt.method, tt.target, rec.Code)
+		}
+		if rec.Header().Get("Retry-After") == "" {
+			t.Errorf("%s %s: no Retry-After header", tt.method, tt.target)
+		}
+		var resp MaintenanceResponse
+		decodeJSON(t, rec, &resp)
+		if resp.Message != cfg.MaintenanceMessage {
+			t.Errorf("%s %s: message %q, want %q", tt.method, tt.target, resp.Message, cfg.MaintenanceMessage)
+		}
+	}
+
+	if rec := serve(h, http.MethodGet, "/healthz", ""); rec.Code != http.StatusOK {
+		t.Errorf("/healthz: status %d, want 200", rec.Code)
+	}
+}
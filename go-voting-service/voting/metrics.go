@@ -0,0 +1,74 @@
+package voting
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// metrics holds the Prometheus collectors of one poll in its own registry
+type metrics struct {
+	registry          *prometheus.Registry
+	votes             *prometheus.CounterVec
+	clientsConnected  prometheus.Gauge
+	votesDropped      prometheus.Counter
+	messagesSkipped   prometheus.Counter
+	candidatesCreated prometheus.Counter
+}
+
+// newMetrics returns the collectors of the poll with the given ID, each labelled with it. Only
+// the default poll's registry carries the process-wide Go and process collectors, so /metrics
+// can gather every poll's registry without duplicates.
+func newMetrics(poll string) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		votes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "votes_total",
+			Help: "Votes counted, by candidate.",
+		}, []string{"candidate"}),
+		clientsConnected: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "sse_clients_connected",
+			Help: "SSE clients currently connected.",
+		}),
+		votesDropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "votes_dropped_total",
+			Help: "Votes rejected because the vote buffer was full or load shedding kicked in.",
+		}),
+		messagesSkipped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "sse_messages_skipped_total",
+			Help: "SSE events not delivered because the client was not keeping up.",
+		}),
+		candidatesCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "candidates_created_total",
+			Help: "Write-in candidates created by a vote.",
+		}),
+	}
+	prometheus.WrapRegistererWith(prometheus.Labels{"poll": poll}, m.registry).MustRegister(
+		m.votes, m.clientsConnected, m.votesDropped, m.messagesSkipped, m.candidatesCreated,
+	)
+	if poll == defaultPollID {
+		m.registry.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+	}
+	return m
+}
+
+// metricsHandler serves the registries of every poll, including polls created later, in the
+// Prometheus exposition format
+func (reg *PollRegistry) metricsHandler() http.Handler {
+	gather := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		reg.mu.RLock()
+		gatherers := make(prometheus.Gatherers, 0, len(reg.polls))
+		for _, p := range reg.polls {
+			gatherers = append(gatherers, p.metrics.registry)
+		}
+		reg.mu.RUnlock()
+		return gatherers.Gather()
+	})
+	return promhttp.HandlerFor(gather, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,110 @@
+package voting
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// gathered returns the value of the named metric whose labels include labels, read through
+// the registry, or -1 if no such sample exists
+func gathered(t *testing.T, m *metrics, name string, labels map[string]string) float64 {
+	t.Helper()
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	samples:
+		for _, metric := range family.GetMetric() {
+			for _, pair := range metric.GetLabel() {
+				if want, ok := labels[pair.GetName()]; ok && want != pair.GetValue() {
+					continue samples
+				}
+			}
+			if c := metric.GetCounter(); c != nil {
+				return c.GetValue()
+			}
+			return metric.GetGauge().GetValue()
+		}
+	}
+	return -1
+}
+
+func TestVotesTotalCounter(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	for _, name := range []string{"Candidate A", "Candidate A", "Candidate A", "Candidate B"} {
+		if rec := postVote(h, name); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "votes", func() bool { return votesFor(vm, "Candidate A") == 3 && votesFor(vm, "Candidate B") == 1 })
+
+	for candidate, want := range map[string]float64{"Candidate A": 3, "Candidate B": 1} {
+		if got := gathered(t, vm.metrics, "votes_total", map[string]string{"candidate": candidate}); got != want {
+			t.Errorf("votes_total{candidate=%q} = %v, want %v", candidate, got, want)
+		}
+	}
+}
+
+func TestClientsConnectedGauge(t *testing.T) {
+	vm := startManager(t, testConfig())
+	stream := openStream(t, newServer(t, vm.Handler()).URL+"/events")
+	stream.nextOf(t, eventSnapshot)
+	if got := gathered(t, vm.metrics, "sse_clients_connected", nil); got != 1 {
+		t.Errorf("sse_clients_connected = %v with one stream open, want 1", got)
+	}
+	stream.resp.Body.Close()
+	waitFor(t, "the gauge to drop", func() bool { return gathered(t, vm.metrics, "sse_clients_connected", nil) == 0 })
+}
+
+func TestMetricsCoverEveryPoll(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	if rec := serve(h, http.MethodPost, "/admin/polls", `{"id":"lunch","candidates":["Pizza","Soup"]}`, adminHeader...); rec.Code != http.StatusCreated {
+		t.Fatalf("creating poll: status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+		t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := serve(h, http.MethodPost, "/polls/lunch/vote", `{"candidate":"Pizza"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("poll vote: status %d, body %s", rec.Code, rec.Body)
+	}
+	lunch, _ := vm.polls.Get("lunch")
+	waitFor(t, "votes", func() bool { return votesFor(vm, "Candidate A") == 1 && votesFor(lunch.VoteManager, "Pizza") == 1 })
+
+	body := serve(h, http.MethodGet, "/metrics", "").Body.String()
+	for _, want := range []string{
+		`votes_total{candidate="Candidate A",poll="default"} 1`,
+		`votes_total{candidate="Pizza",poll="lunch"} 1`,
+		`sse_clients_connected{poll="lunch"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics lacks %s", want)
+		}
+	}
+	if n := strings.Count(body, "\ngo_goroutines "); n != 1 {
+		t.Errorf("go_goroutines appears %d times, want once", n)
+	}
+}
+
+func TestCandidatesCreatedCounter(t *testing.T) {
+	cfg := testConfig()
+	cfg.AutoCreateCandidates = true
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+	for _, name := range []string{"Write-in 1", "Write-in 2", "Write-in 1"} {
+		if rec := postVote(h, name); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "write-ins", func() bool { return votesFor(vm, "Write-in 1") == 2 && votesFor(vm, "Write-in 2") == 1 })
+
+	if got := gathered(t, vm.metrics, "candidates_created_total", nil); got != 2 {
+		t.Errorf("candidates_created_total = %v, want 2", got)
+	}
+}
@@ -0,0 +1,34 @@
+package voting
+
+// eventMilestone is the SSE event type sent when a candidate crosses a milestone
+const eventMilestone = "milestone"
+
+// Milestone is the payload of a milestone event
+type Milestone struct {
+	Name      string `json:"name"`
+	Milestone int    `json:"milestone"`
+	Votes     int    `json:"votes"`
+
+	EventMeta
+}
+
+// checkMilestone broadcasts a milestone event the first time candidate reaches each multiple of
+// MilestoneEvery. It runs on the vote-processing goroutine, which owns candidate.milestone.
+func (vm *VoteManager) checkMilestone(candidate *Candidate) {
+	every := vm.cfg.MilestoneEvery
+	if every <= 0 {
+		return
+	}
+	reached := candidate.Votes / every * every
+	if reached == 0 || reached <= candidate.milestone {
+		return
+	}
+	// Milestones past the display cap would reveal the exact count
+	if limit := vm.cfg.ResultsDisplayCap; limit > 0 && reached > limit {
+		return
+	}
+	candidate.milestone = reached
+
+	votes, _ := capped(candidate.Votes, vm.cfg.ResultsDisplayCap)
+	vm.broadcast(eventMilestone, &Milestone{Name: candidate.Name, Milestone: reached, Votes: votes})
+}
@@ -0,0 +1,47 @@
+package voting
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestMilestoneFiresOncePerCrossing(t *testing.T) {
+	cfg := testConfig()
+	cfg.MilestoneEvery = 3
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+	stream := openStream(t, newServer(t, h).URL+"/events")
+	stream.nextOf(t, eventSnapshot)
+
+	// Five votes cross 3 once and stay below 6
+	for range 5 {
+		if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "votes", func() bool { return votesFor(vm, "Candidate A") == 5 })
+	// A vote for B is broadcast after every event the votes for A caused
+	if rec := postVote(h, "Candidate B"); rec.Code != http.StatusAccepted {
+		t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	var milestones []Milestone
+	for {
+		ev := stream.next(t)
+		if ev.Event == eventMilestone {
+			var m Milestone
+			if err := json.Unmarshal([]byte(ev.Data), &m); err != nil {
+				t.Fatalf("invalid milestone %q: %v", ev.Data, err)
+			}
+			milestones = append(milestones, m)
+			continue
+		}
+		if ev.Event == eventUpdate && ev.results(t).votes("Candidate B") == 1 {
+			break
+		}
+	}
+	if len(milestones) != 1 || milestones[0].Name != "Candidate A" || milestones[0].Milestone != 3 {
+		t.Errorf("milestones %+v, want one for Candidate A at 3", milestones)
+	}
+}
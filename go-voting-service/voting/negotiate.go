@@ -0,0 +1,76 @@
+package voting
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// negotiate picks the offered media type best matching the request's Accept header.
+// Each offer takes the q value of the most specific range matching it, as RFC 9110 requires,
+// so "application/json;q=0, */*" still refuses JSON. A missing Accept header selects the
+// first offer; ok is false when nothing acceptable is offered.
+func negotiate(accept string, offers ...string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		offerType, _, _ := strings.Cut(offer, "/")
+		// specificity ranks the matching range: 1 for */*, 2 for type/*, 3 for the exact type
+		offerQ, specificity := 0.0, 0
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			q := 1.0
+			if raw, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(raw, 64); err != nil {
+					continue
+				}
+			}
+			rangeType, rangeSub, _ := strings.Cut(mediaType, "/")
+			rank := 0
+			switch {
+			case mediaType == offer:
+				rank = 3
+			case rangeSub == "*" && rangeType == offerType:
+				rank = 2
+			case mediaType == "*/*":
+				rank = 1
+			}
+			if rank > specificity || (rank == specificity && rank > 0 && q > offerQ) {
+				offerQ, specificity = q, rank
+			}
+		}
+		if offerQ > bestQ {
+			best, bestQ = offer, offerQ
+		}
+	}
+	return best, bestQ > 0
+}
+
+// acceptsEncoding reports whether the request's Accept-Encoding header allows coding
+func acceptsEncoding(acceptEncoding, coding string) bool {
+	accepted := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || (name != coding && name != "*") {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		// An explicit entry for coding overrides the wildcard
+		if name == coding {
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
@@ -0,0 +1,64 @@
+package voting
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "text/csv"}
+	for _, tt := range []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"", "application/json", true},
+		{"text/csv", "text/csv", true},
+		{"text/*", "text/csv", true},
+		{"*/*", "application/json", true},
+		{"application/json;q=0.5, text/csv", "text/csv", true},
+		{"application/json;q=0, */*", "text/csv", true},
+		{"text/csv;q=0, text/*", "", false},
+		{"image/png", "", false},
+	} {
+		got, ok := negotiate(tt.accept, offers...)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("negotiate(%q) = %q, %v; want %q, %v", tt.accept, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	for _, tt := range []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=0.5", true},
+		{"gzip;q=0", false},
+		{"*", true},
+		{"*, gzip;q=0", false},
+	} {
+		if got := acceptsEncoding(tt.header, "gzip"); got != tt.want {
+			t.Errorf("acceptsEncoding(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestResultsContentNegotiation(t *testing.T) {
+	h := startManager(t, testConfig()).Handler()
+
+	if rec := serve(h, http.MethodGet, "/results", "", "Accept", "application/xml"); rec.Code != http.StatusNotAcceptable {
+		t.Errorf("Accept application/xml: status %d, want 406", rec.Code)
+	}
+	rec := serve(h, http.MethodGet, "/results", "", "Accept", "*/*")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Accept */*: status %d, Content-Type %q; want 200 JSON", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	rec = serve(h, http.MethodGet, "/results", "", "Accept", "application/json;q=0, */*")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("Accept refusing JSON: status %d, Content-Type %q; want 200 CSV", rec.Code, rec.Header().Get("Content-Type"))
+	}
+}
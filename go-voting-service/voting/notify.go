@@ -0,0 +1,19 @@
+package voting
+
+import "log/slog"
+
+// VoterNotifier is called after an identified vote is counted, e.g. to email a confirmation
+type VoterNotifier func(voterID, candidate string) error
+
+// notifyVoter runs the NotifyVoter hook on its own goroutine, so a slow or failing hook never
+// delays counting. Anonymous votes are not notified, even when a voter cookie dedups them.
+func (vm *VoteManager) notifyVoter(v vote) {
+	if vm.NotifyVoter == nil || v.identity == "" {
+		return
+	}
+	go func() {
+		if err := vm.NotifyVoter(v.identity, v.candidate); err != nil {
+			slog.Warn("Vote confirmation failed", "voter", v.identity, "error", err)
+		}
+	}()
+}
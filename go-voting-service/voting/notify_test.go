@@ -0,0 +1,71 @@
+package voting
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestNotifyVoterOncePerAcceptedVote(t *testing.T) {
+	cfg := testConfig()
+	cfg.Poll.RequireVoterID = true
+	cfg.Poll.VoterMode = voterModeOnce
+
+	type call struct{ voter, candidate string }
+	calls := make(chan call, 10)
+	vm := newManager(t, cfg)
+	vm.NotifyVoter = func(voterID, candidate string) error {
+		calls <- call{voterID, candidate}
+		if voterID == "bob" {
+			return errors.New("mail server down")
+		}
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	vm.Start(ctx)
+	defer vm.Stop()
+	h := vm.Handler()
+
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"candidate":"Candidate A","voter":"alice"}`, http.StatusAccepted},
+		{`{"candidate":"Candidate B","voter":"bob"}`, http.StatusAccepted},
+		{`{"candidate":"Candidate B","voter":"alice"}`, http.StatusConflict},
+		{`{"candidate":"Candidate A"}`, http.StatusBadRequest},
+		{`{"candidate":"Candidate A","voter":"carol"}`, http.StatusAccepted},
+	} {
+		if rec := serve(h, http.MethodPost, "/vote", tc.body); rec.Code != tc.want {
+			t.Fatalf("vote %s: status %d, want %d", tc.body, rec.Code, tc.want)
+		}
+	}
+
+	var got []call
+	for range 3 {
+		select {
+		case c := <-calls:
+			got = append(got, c)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("hook called %d times, want 3", len(got))
+		}
+	}
+	select {
+	case c := <-calls:
+		t.Errorf("unexpected extra call %+v", c)
+	case <-time.After(50 * time.Millisecond):
+	}
+	slices.SortFunc(got, func(a, b call) int { return cmp.Compare(a.voter, b.voter) })
+	want := []call{{"alice", "Candidate A"}, {"bob", "Candidate B"}, {"carol", "Candidate A"}}
+	if !slices.Equal(got, want) {
+		t.Errorf("hook calls %+v, want %+v", got, want)
+	}
+
+	// A failing hook leaves the vote counted
+	waitFor(t, "votes", func() bool { return votesFor(vm, "Candidate A") == 2 && votesFor(vm, "Candidate B") == 1 })
+}
@@ -0,0 +1,199 @@
+package voting
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// maxPollIDLength bounds poll IDs accepted from admins
+const maxPollIDLength = 64
+
+var (
+	errPollExists    = errors.New("poll already exists")
+	errPollLimit     = errors.New("poll limit reached")
+	errInvalidPollID = errors.New("poll ID must be 1-64 lowercase letters, digits, '-' or '_'")
+)
+
+// Poll is one independent question. Its VoteManager owns the candidate map, vote channel and
+// SSE client set, so votes and stream updates never cross between polls.
+type Poll struct {
+	ID string
+	*VoteManager
+}
+
+// PollRequest is the body accepted by POST /admin/polls. Poll sets the new poll's identity
+// rules; without it the poll gets the server's defaults.
+type PollRequest struct {
+	ID         string      `json:"id"`
+	Candidates []string    `json:"candidates"`
+	Poll       *PollConfig `json:"poll,omitempty"`
+}
+
+// PollRegistry holds the running polls keyed by ID. The default poll is the one served by the
+// top-level endpoints; polls created later live until shutdown.
+type PollRegistry struct {
+	mu    sync.RWMutex
+	ctx   context.Context
+	cfg   Config
+	polls map[string]*Poll
+}
+
+// newPollRegistry returns a registry serving def as the default poll. Polls created later are
+// started with the default poll's context and use cfg without persistence, export or auditing, which
+// belong to the default poll.
+func newPollRegistry(cfg Config, def *VoteManager) *PollRegistry {
+	cfg.DataFile = ""
+	cfg.ExportURL = ""
+	cfg.AuditLogFile = ""
+	cfg.CandidatesFile = ""
+	return &PollRegistry{
+		ctx:   context.Background(),
+		cfg:   cfg,
+		polls: map[string]*Poll{defaultPollID: {ID: defaultPollID, VoteManager: def}},
+	}
+}
+
+// setContext sets the context polls created from now on are started with
+func (reg *PollRegistry) setContext(ctx context.Context) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	reg.ctx = ctx
+}
+
+// validPollID reports whether id is safe to use as a path segment
+func validPollID(id string) bool {
+	if id == "" || len(id) > maxPollIDLength {
+		return false
+	}
+	for _, r := range id {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+// Get returns the poll with the given ID
+func (reg *PollRegistry) Get(id string) (*Poll, bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	p, ok := reg.polls[id]
+	return p, ok
+}
+
+// IDs returns the poll IDs in order
+func (reg *PollRegistry) IDs() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	ids := make([]string, 0, len(reg.polls))
+	for id := range reg.polls {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Create starts a new poll with the given candidates, enforcing rules on its voters
+func (reg *PollRegistry) Create(id string, names []string, rules PollConfig) (*Poll, error) {
+	if !validPollID(id) {
+		return nil, errInvalidPollID
+	}
+	rules.VoterMode = cmp.Or(rules.VoterMode, voterModeUnlimited)
+	if err := rules.validate(); err != nil {
+		return nil, err
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, exists := reg.polls[id]; exists {
+		return nil, errPollExists
+	}
+	if len(reg.polls) >= reg.cfg.MaxPolls {
+		return nil, errPollLimit
+	}
+
+	cfg := reg.cfg
+	cfg.Poll = rules
+	vm, err := newVoteManager(id, cfg)
+	if err != nil {
+		return nil, err
+	}
+	vm.Start(reg.ctx)
+	if err := vm.ReplaceCandidates(names); err != nil {
+		vm.Stop()
+		return nil, err
+	}
+	p := &Poll{ID: id, VoteManager: vm}
+	reg.polls[id] = p
+	slog.Info("Created poll", "poll", id)
+	return p, nil
+}
+
+// Stop stops every poll but the default one, which its owner stops
+func (reg *PollRegistry) Stop() {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for id, p := range reg.polls {
+		if id != defaultPollID {
+			p.Stop()
+		}
+	}
+}
+
+// DisconnectClients ends the SSE streams of every poll
+func (reg *PollRegistry) DisconnectClients() {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	for _, p := range reg.polls {
+		p.disconnectClients()
+	}
+}
+
+// route serves a /polls/{id}/... request with h on the poll named by the path,
+// answering 404 for unknown polls
+func (reg *PollRegistry) route(h func(*VoteManager, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := reg.Get(r.PathValue("id"))
+		if !ok {
+			writeJSONError(w, http.StatusNotFound, "Poll not found")
+			return
+		}
+		h(p.VoteManager, w, r)
+	}
+}
+
+// listPollsHandler returns the IDs of every poll
+func (reg *PollRegistry) listPollsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, reg.IDs())
+}
+
+// createPollHandler starts a new poll and returns its initial results
+func (reg *PollRegistry) createPollHandler(w http.ResponseWriter, r *http.Request) {
+	if reg.cfg.ReadOnly {
+		writeJSONError(w, http.StatusServiceUnavailable, "Creating polls is unavailable: server is read-only")
+		return
+	}
+	var req PollRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "Invalid poll request")
+		return
+	}
+	rules := reg.cfg.Poll
+	if req.Poll != nil {
+		rules = *req.Poll
+	}
+	p, err := reg.Create(req.ID, req.Candidates, rules)
+	switch err {
+	case nil:
+		writeJSON(w, http.StatusCreated, p.snapshot())
+	case errPollExists, errPollLimit, errCandidateLimit:
+		writeJSONError(w, http.StatusConflict, err.Error())
+	default:
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	}
+}
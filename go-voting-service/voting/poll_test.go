@@ -0,0 +1,72 @@
+package voting
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPollsWithDifferentIdentityRulesCoexist(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+
+	for _, body := range []string{
+		`{"id":"anonymous","candidates":["Yes","No"]}`,
+		`{"id":"identified","candidates":["Yes","No"],"poll":{"requireVoterId":true,"voterMode":"once"}}`,
+	} {
+		if rec := serve(h, http.MethodPost, "/admin/polls", body, adminHeader...); rec.Code != http.StatusCreated {
+			t.Fatalf("creating poll %s: status %d, body %s", body, rec.Code, rec.Body)
+		}
+	}
+
+	// The anonymous poll counts every anonymous vote
+	for range 2 {
+		if rec := serve(h, http.MethodPost, "/polls/anonymous/vote", `{"candidate":"Yes"}`); rec.Code != http.StatusAccepted {
+			t.Fatalf("anonymous vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	anonymous, _ := vm.polls.Get("anonymous")
+	waitFor(t, "anonymous votes", func() bool { return votesFor(anonymous.VoteManager, "Yes") == 2 })
+
+	// The identified poll rejects anonymous votes and counts each voter once
+	if rec := serve(h, http.MethodPost, "/polls/identified/vote", `{"candidate":"Yes"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("anonymous vote on identified poll: status %d, want 400", rec.Code)
+	}
+	if rec := serve(h, http.MethodPost, "/polls/identified/vote", `{"candidate":"Yes","voter":"alice"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("identified vote: status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := serve(h, http.MethodPost, "/polls/identified/vote", `{"candidate":"No","voter":"alice"}`); rec.Code != http.StatusConflict {
+		t.Fatalf("repeat vote on once poll: status %d, want 409", rec.Code)
+	}
+	identified, _ := vm.polls.Get("identified")
+	waitFor(t, "identified vote", func() bool { return votesFor(identified.VoteManager, "Yes") == 1 })
+	if got := votesFor(identified.VoteManager, "No"); got != 0 {
+		t.Errorf("No has %d votes on the identified poll, want 0", got)
+	}
+}
+
+func TestCreatePollRejectsUnknownVoterMode(t *testing.T) {
+	vm := startManager(t, testConfig())
+	rec := serve(vm.Handler(), http.MethodPost, "/admin/polls", `{"id":"p","candidates":["A"],"poll":{"voterMode":"twice"}}`, adminHeader...)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", rec.Code)
+	}
+}
+
+func TestFreshPollSnapshotListsCandidatesAtZero(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	if rec := serve(h, http.MethodPost, "/admin/polls", `{"id":"fresh","candidates":["Red","Green","Blue"]}`, adminHeader...); rec.Code != http.StatusCreated {
+		t.Fatalf("creating poll: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	ev := openStream(t, newServer(t, h).URL+"/polls/fresh/events").nextOf(t, eventSnapshot)
+	snapshot := ev.results(t)
+	if snapshot.Total != 0 || len(snapshot.Candidates) != 3 {
+		t.Fatalf("snapshot %s, want three candidates and a zero total", ev.Data)
+	}
+	for _, c := range snapshot.Candidates {
+		if c.Votes != 0 || c.Percentage != 0 {
+			t.Errorf("%s starts at %d votes, %v%%; want 0", c.Name, c.Votes, c.Percentage)
+		}
+	}
+}
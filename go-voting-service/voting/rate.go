@@ -0,0 +1,62 @@
+package voting
+
+import (
+	"net/http"
+	"time"
+)
+
+// rateWindow is how far back a candidate's vote rate looks
+const rateWindow = time.Minute
+
+// rateBuckets splits rateWindow into one-second buckets
+const rateBuckets = int(rateWindow / time.Second)
+
+// voteRate counts the votes of the last rateWindow in a ring of per-second buckets. A bucket
+// is reused once its second has left the window, so adding a vote is O(1) and a quiet
+// candidate's rate falls to zero on its own.
+type voteRate struct {
+	counts [rateBuckets]int
+	second [rateBuckets]int64 // Unix second each bucket counts
+}
+
+// add counts weight votes in the bucket for now
+func (r *voteRate) add(now time.Time, weight int) {
+	sec := now.Unix()
+	i := sec % int64(rateBuckets)
+	if r.second[i] != sec {
+		r.counts[i], r.second[i] = 0, sec
+	}
+	r.counts[i] += weight
+}
+
+// valueAt returns the votes counted in the rateWindow up to now
+func (r *voteRate) valueAt(now time.Time) int {
+	sec := now.Unix()
+	total := 0
+	for i, count := range r.counts {
+		if age := sec - r.second[i]; age >= 0 && age < int64(rateBuckets) {
+			total += count
+		}
+	}
+	return total
+}
+
+// VoteRates is the payload returned by /rates: each candidate's votes in the last Window
+type VoteRates struct {
+	Window string         `json:"window"`
+	Rates  map[string]int `json:"rates"`
+}
+
+// ratesHandler returns every candidate's votes per minute. Candidates above
+// RESULTS_DISPLAY_CAP are left out, since their rate would count the votes the cap hides.
+func (vm *VoteManager) ratesHandler(w http.ResponseWriter, r *http.Request) {
+	rates := VoteRates{Window: rateWindow.String(), Rates: make(map[string]int)}
+	candidates := vm.candidateList()
+	applyDisplayCap(candidates, vm.cfg.ResultsDisplayCap)
+	for _, c := range candidates {
+		if !c.Capped {
+			rates.Rates[c.Name] = c.Rate
+		}
+	}
+	writeJSON(w, http.StatusOK, rates)
+}
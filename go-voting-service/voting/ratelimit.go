@@ -0,0 +1,150 @@
+package voting
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenBucket allows rate events per second on average with bursts of up to burst.
+// A zero rate disables the limit.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	b := float64(max(burst, 1))
+	return &tokenBucket{rate: rate, burst: b, tokens: b}
+}
+
+// allow takes a token if one is available, otherwise reporting how long until one will be
+func (b *tokenBucket) allow(now time.Time) (bool, time.Duration) {
+	if b.rate == 0 {
+		return true, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// retryAfter formats d as whole seconds for a Retry-After header, rounding up
+func retryAfter(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// ipLimiter keeps a tokenBucket per client IP. Buckets idle long enough to have refilled are
+// evicted, since a fresh bucket behaves identically, so the map only holds active clients.
+type ipLimiter struct {
+	mu        sync.Mutex
+	rate      float64
+	burst     int
+	idle      time.Duration
+	buckets   map[string]*ipBucket
+	lastSweep time.Time
+}
+
+type ipBucket struct {
+	bucket *tokenBucket
+	seen   time.Time
+}
+
+func newIPLimiter(rate float64, burst int) *ipLimiter {
+	idle := time.Minute
+	if rate > 0 {
+		idle = max(idle, time.Duration(float64(max(burst, 1))/rate*float64(time.Second)))
+	}
+	return &ipLimiter{rate: rate, burst: burst, idle: idle, buckets: make(map[string]*ipBucket)}
+}
+
+// allow takes a token from ip's bucket, otherwise reporting how long until one will be
+func (l *ipLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	if l.rate == 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	if now.Sub(l.lastSweep) >= l.idle {
+		for key, b := range l.buckets {
+			if now.Sub(b.seen) >= l.idle {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastSweep = now
+	}
+	b, ok := l.buckets[ip]
+	if !ok {
+		b = &ipBucket{bucket: newTokenBucket(l.rate, l.burst)}
+		l.buckets[ip] = b
+	}
+	b.seen = now
+	l.mu.Unlock()
+	return b.bucket.allow(now)
+}
+
+// clientIP returns the address of the client behind r. X-Forwarded-For is only honored when
+// the direct peer is a trusted proxy, and is read right to left so a client cannot spoof it.
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !isTrusted(host, trusted) {
+		return host
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		host = hop
+		if !isTrusted(hop, trusted) {
+			break
+		}
+	}
+	return host
+}
+
+// isTrusted reports whether ip falls inside one of the trusted prefixes
+func isTrusted(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimit answers 429 with Retry-After once the client's IP exceeds VoteRate
+func (vm *VoteManager) rateLimit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := vm.voteLimiter.allow(clientIP(r, vm.cfg.TrustedProxies), vm.clock.Now()); !ok {
+			vm.votesThrottled.Add(1)
+			w.Header().Set("Retry-After", retryAfter(wait))
+			writeJSONError(w, http.StatusTooManyRequests, "Too many votes from this address")
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,63 @@
+package voting
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+)
+
+// startReadOnly saves a vote for Candidate A to a data file and returns a read-only manager
+// that loaded it
+func startReadOnly(t *testing.T) *VoteManager {
+	t.Helper()
+	cfg := testConfig()
+	cfg.DataFile = filepath.Join(t.TempDir(), "votes.json")
+
+	writer := startManager(t, cfg)
+	if rec := postVote(writer.Handler(), "Candidate A"); rec.Code != http.StatusAccepted {
+		t.Fatalf("seeding vote: status %d, body %s", rec.Code, rec.Body)
+	}
+	writer.Stop()
+
+	cfg.ReadOnly = true
+	return startManager(t, cfg)
+}
+
+func TestReadOnlyRejectsVotesAndServesResults(t *testing.T) {
+	vm := startReadOnly(t)
+	h := vm.Handler()
+
+	if rec := postVote(h, "Candidate A"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("vote: status %d, want 503", rec.Code)
+	}
+
+	rec := serve(h, http.MethodGet, "/results", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("results: status %d, body %s", rec.Code, rec.Body)
+	}
+	var results ResultsPayload
+	decodeJSON(t, rec, &results)
+	for _, c := range results.Candidates {
+		if c.Name == "Candidate A" && c.Votes != 1 {
+			t.Errorf("Candidate A has %d votes, want the saved 1", c.Votes)
+		}
+	}
+}
+
+func TestReadOnlyRejectsAdminWrites(t *testing.T) {
+	h := startReadOnly(t).Handler()
+
+	for _, tt := range []struct {
+		method, target, body string
+	}{
+		{http.MethodPost, "/reset", ""},
+		{http.MethodPost, "/candidates", `{"name":"Candidate C"}`},
+		{http.MethodDelete, "/candidates?name=Candidate+A", ""},
+		{http.MethodPut, "/admin/candidates", `{"candidates":["X","Y"]}`},
+		{http.MethodPost, "/admin/polls", `{"id":"p","candidates":["X","Y"]}`},
+	} {
+		if rec := serve(h, tt.method, tt.target, tt.body, adminHeader...); rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s %s: status %d, want 503", tt.method, tt.target, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package voting
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventMeta stamps every broadcast payload with its sequence, which matches the SSE id line,
+// and the server time it was sent, so clients can show staleness and drop out-of-order events
+type EventMeta struct {
+	Seq uint64 `json:"seq"`
+	TS  string `json:"ts"`
+}
+
+// setMeta is promoted to every payload embedding EventMeta
+func (m *EventMeta) setMeta(meta EventMeta) {
+	*m = meta
+}
+
+// newEventMeta returns the stamp for event seq sent at now
+func newEventMeta(seq uint64, now time.Time) EventMeta {
+	return EventMeta{Seq: seq, TS: now.UTC().Format(time.RFC3339)}
+}
+
+// broadcastPayload is a payload that can be stamped with its EventMeta
+type broadcastPayload interface {
+	setMeta(EventMeta)
+}
+
+// replayEntry is one broadcast kept for Last-Event-ID replay. Results snapshots keep the
+// ResultsPayload so they can be encoded in each client's ?sort order.
+type replayEntry struct {
+	seq      uint64
+	ev       sseEvent
+	snapshot *ResultsPayload
+}
+
+// eventRing keeps the last size broadcasts. It also hands out the sequence numbers, so IDs and
+// buffer order always agree.
+type eventRing struct {
+	mu      sync.Mutex
+	size    int // 0 disables replay
+	entries []replayEntry
+}
+
+// record assigns ev the next sequence number from seq as its ID, stamps payload with it and
+// buffers the event. Results snapshots are kept as payloads and encoded per client; any other
+// payload is encoded into ev's data here.
+func (er *eventRing) record(seq *atomic.Uint64, now time.Time, ev sseEvent, payload broadcastPayload) (sseEvent, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	n := seq.Add(1)
+	ev.ID = strconv.FormatUint(n, 10)
+	payload.setMeta(newEventMeta(n, now))
+	snapshot, isSnapshot := payload.(*ResultsPayload)
+	if !isSnapshot {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			// The sequence moved on without an event, so the buffer can no longer bridge it
+			er.entries = nil
+			return ev, err
+		}
+		ev.Data = string(data)
+		snapshot = nil
+	}
+	if er.size > 0 {
+		er.entries = append(er.entries, replayEntry{seq: n, ev: ev, snapshot: snapshot})
+		if len(er.entries) > er.size {
+			er.entries = er.entries[1:]
+		}
+	}
+	return ev, nil
+}
+
+// skip advances seq for a change nobody was sent. The buffer can no longer bridge the gap,
+// so it is emptied and reconnecting clients get a full snapshot instead.
+func (er *eventRing) skip(seq *atomic.Uint64) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	seq.Add(1)
+	er.entries = nil
+}
+
+// since returns the buffered events after the given ID. It reports false when the events
+// in between are no longer buffered, or the ID was never issued by this server.
+func (er *eventRing) since(after uint64, seq *atomic.Uint64) ([]replayEntry, bool) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	current := seq.Load()
+	switch {
+	case after > current:
+		return nil, false
+	case after == current:
+		return nil, true
+	case len(er.entries) == 0 || after+1 < er.entries[0].seq:
+		return nil, false
+	}
+	start := len(er.entries) - int(current-after)
+	return append([]replayEntry(nil), er.entries[start:]...), true
+}
+
+// resume parses a Last-Event-ID header and returns the events the client missed,
+// reporting false when it must be sent the full snapshot instead
+func (vm *VoteManager) resume(lastEventID string) ([]replayEntry, bool) {
+	if lastEventID == "" {
+		return nil, false
+	}
+	after, err := strconv.ParseUint(lastEventID, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return vm.replay.since(after, &vm.seq)
+}
+
+// event returns the entry as sent to a client with the given ?sort order
+func (e replayEntry) event(sortMode string) sseEvent {
+	if e.snapshot == nil {
+		return e.ev
+	}
+	ev := e.ev
+	ev.Data = encodeResults(e.snapshot.sorted(sortMode))
+	return ev
+}
+
+// streamCursor tracks the newest event ID a client has been sent, so live events that
+// were already replayed are not sent twice
+type streamCursor struct {
+	last uint64
+}
+
+// seen reports whether ev was already sent, advancing the cursor when it was not.
+// Events without a numeric ID are never considered seen.
+func (c *streamCursor) seen(ev sseEvent) bool {
+	id, err := strconv.ParseUint(ev.ID, 10, 64)
+	if err != nil {
+		return false
+	}
+	if id <= c.last {
+		return true
+	}
+	c.last = id
+	return false
+}
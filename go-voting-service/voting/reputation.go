@@ -0,0 +1,24 @@
+package voting
+
+import "log/slog"
+
+// ReputationFunc returns the vote weight for a voter, e.g. from an external identity system.
+// It multiplies the weight requested with the vote.
+type ReputationFunc func(voterID string) (int, error)
+
+// voteWeight resolves the weight of a vote on the processing goroutine.
+// Anonymous votes, including those deduped by a voter cookie, a missing lookup, a failed lookup or a non-positive weight all count as 1.
+func (vm *VoteManager) voteWeight(voterID string) int {
+	if vm.Reputation == nil || voterID == "" {
+		return 1
+	}
+	weight, err := vm.Reputation(voterID)
+	if err != nil {
+		slog.Warn("Reputation lookup failed, using weight 1", "voter", voterID, "error", err)
+		return 1
+	}
+	if weight < 1 {
+		return 1
+	}
+	return weight
+}
@@ -0,0 +1,86 @@
+package voting
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReputationWeightsVotes(t *testing.T) {
+	vm := startManager(t, testConfig())
+	vm.Reputation = func(voterID string) (int, error) {
+		switch voterID {
+		case "trusted":
+			return 5, nil
+		case "broken":
+			return 0, errors.New("lookup failed")
+		}
+		return 1, nil
+	}
+	h := vm.Handler()
+
+	for _, voter := range []string{"trusted", "newcomer", "broken"} {
+		body := `{"candidate":"Candidate A","voter":"` + voter + `"}`
+		if rec := serve(h, http.MethodPost, "/vote", body); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote by %s: status %d, body %s", voter, rec.Code, rec.Body)
+		}
+	}
+	// 5 for the trusted voter, 1 each for the newcomer and the failed lookup
+	waitFor(t, "weighted votes", func() bool { return votesFor(vm, "Candidate A") == 7 })
+
+	// Anonymous votes are never looked up
+	if rec := postVote(h, "Candidate B"); rec.Code != http.StatusAccepted {
+		t.Fatalf("anonymous vote: status %d", rec.Code)
+	}
+	waitFor(t, "the anonymous vote", func() bool { return votesFor(vm, "Candidate B") == 1 })
+}
+
+func TestChangedVoteRetractsCountedWeight(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		before, after int64
+	}{
+		{"weight drops", 5, 1},
+		{"weight rises", 1, 5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.Poll.VoterMode = voterModeChange
+			var aliceWeight atomic.Int64
+			aliceWeight.Store(tc.before)
+			vm := newManager(t, cfg)
+			vm.Reputation = func(voterID string) (int, error) {
+				if voterID == "alice" {
+					return int(aliceWeight.Load()), nil
+				}
+				return 1, nil
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			vm.Start(ctx)
+			defer vm.Stop()
+			h := vm.Handler()
+
+			vote := func(voter, candidate string) {
+				t.Helper()
+				body := `{"candidate":"` + candidate + `","voter":"` + voter + `"}`
+				if rec := serve(h, http.MethodPost, "/vote", body); rec.Code != http.StatusAccepted {
+					t.Fatalf("vote by %s: status %d, body %s", voter, rec.Code, rec.Body)
+				}
+			}
+			vote("bob", "Candidate A")
+			vote("alice", "Candidate A")
+			waitFor(t, "the first votes", func() bool { return votesFor(vm, "Candidate A") == 1+int(tc.before) })
+
+			// Alice moves to B with a new weight; A loses only what her ballot added
+			aliceWeight.Store(tc.after)
+			vote("alice", "Candidate B")
+			waitFor(t, "the changed vote", func() bool { return votesFor(vm, "Candidate B") == int(tc.after) })
+			if got := votesFor(vm, "Candidate A"); got != 1 {
+				t.Errorf("Candidate A has %d votes after the change, want bob's 1", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package voting
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Reset zeroes every candidate's votes on the vote-processing goroutine and broadcasts the
+// cleared results. Votes already in voteChannel are applied first; votes admitted before the
+// reset but not yet queued carry the old epoch and are discarded, so none lands afterwards.
+// Ballots, picks and the vote history are cleared too, so everyone may vote again.
+func (vm *VoteManager) Reset() error {
+	ok := vm.do(func() {
+		vm.mu.Lock()
+		for _, c := range vm.candidates {
+			c.Votes = 0
+			c.decay = decay{}
+			c.rate = voteRate{}
+			c.milestone = 0
+		}
+		vm.epoch.Add(1)
+		clear(vm.votedBy)
+		clear(vm.ballots)
+		vm.dirty.Store(true)
+		vm.mu.Unlock()
+		vm.picks.reset()
+		vm.history.reset()
+		vm.auditRecord(AuditRecord{Time: vm.clock.Now(), Action: auditActionReset})
+		vm.notifyClients(eventSnapshot)
+	})
+	if !ok {
+		return errStopped
+	}
+	slog.Info("Votes reset")
+	return nil
+}
+
+// resetHandler zeroes all votes and returns the cleared results
+func (vm *VoteManager) resetHandler(w http.ResponseWriter, r *http.Request) {
+	if vm.cfg.ReadOnly {
+		writeJSONError(w, http.StatusServiceUnavailable, "Reset is unavailable: server is read-only")
+		return
+	}
+	if err := vm.Reset(); err != nil {
+		writeJSONError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, vm.snapshot())
+}
@@ -0,0 +1,79 @@
+package voting
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentVotesAndResults runs under -race: HTTP goroutines read results and stream
+// snapshots while the processing goroutine counts votes, and every snapshot must add up
+func TestConcurrentVotesAndResults(t *testing.T) {
+	const voters, votesEach = 8, 25
+	cfg := testConfig()
+	// Votes are enqueued without blocking; leave room for all of them so none is turned away
+	cfg.VoteBuffer = voters * votesEach
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+
+	// SSE clients joining and leaving while votes are fanned out to them
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var streams sync.WaitGroup
+	for range 4 {
+		streams.Add(1)
+		go func() {
+			defer streams.Done()
+			for ctx.Err() == nil {
+				reqCtx, stop := context.WithCancel(ctx)
+				req := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(reqCtx)
+				done := make(chan struct{})
+				go func() {
+					h.ServeHTTP(httptest.NewRecorder(), req)
+					close(done)
+				}()
+				stop()
+				<-done
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for i := range voters {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			candidate := []string{"Candidate A", "Candidate B"}[i%2]
+			for range votesEach {
+				if rec := postVote(h, candidate); rec.Code != http.StatusAccepted {
+					t.Errorf("vote: status %d, body %s", rec.Code, rec.Body)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range votesEach {
+				var results ResultsPayload
+				decodeJSON(t, serve(h, http.MethodGet, "/results", ""), &results)
+				sum := 0
+				for _, c := range results.Candidates {
+					sum += c.Votes
+				}
+				if sum != results.Total {
+					t.Errorf("snapshot candidates add up to %d, total says %d", sum, results.Total)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	cancel()
+	streams.Wait()
+
+	waitFor(t, "every vote", func() bool {
+		return votesFor(vm, "Candidate A")+votesFor(vm, "Candidate B") == voters*votesEach
+	})
+}
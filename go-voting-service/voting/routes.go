@@ -0,0 +1,137 @@
+package voting
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// api wraps a non-SSE handler with CORS, security headers and the response timeout
+func (vm *VoteManager) api(h http.HandlerFunc) http.Handler {
+	return corsMiddleware(vm.cfg.CORS, securityHeadersMiddleware(vm.cfg.SecurityHeaders, timeoutMiddleware(vm.cfg.HandlerTimeout, h)))
+}
+
+// longPoll wraps longPollHandler with CORS and security headers but no response timeout
+func (vm *VoteManager) longPoll() http.Handler {
+	return corsMiddleware(vm.cfg.CORS, securityHeadersMiddleware(vm.cfg.SecurityHeaders, http.HandlerFunc(vm.longPollHandler)))
+}
+
+// admin wraps a mutating or privileged handler with api and the bearer token check
+func (vm *VoteManager) admin(h http.HandlerFunc) http.Handler {
+	return adminAuth(vm.cfg.AdminToken, vm.api(h))
+}
+
+// corsAllowMethods and corsAllowHeaders are every method and request header the routes accept
+const (
+	corsAllowMethods = "GET, POST, PUT, DELETE, OPTIONS"
+	corsAllowHeaders = "Content-Type, Authorization"
+)
+
+// bareRoutes are served without any middleware, CORS included
+var bareRoutes = []string{"/healthz", "/readyz", "/metrics", "/ws"}
+
+// serveMux answers OPTIONS requests through corsMiddleware before they reach mux, whose
+// method-specific patterns would otherwise reject them with 405; on bareRoutes they go to mux
+// like any other method. Requests no route matches get mux's own 404 or 405, Allow header
+// included, with a JSON body.
+func (vm *VoteManager) serveMux(mux *http.ServeMux) http.Handler {
+	answer := corsMiddleware(vm.cfg.CORS, mux)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions && !slices.Contains(bareRoutes, r.URL.Path) {
+			answer.ServeHTTP(w, r)
+			return
+		}
+		if h, pattern := mux.Handler(r); pattern == "" {
+			rec := &bufferedResponse{header: w.Header(), status: http.StatusOK}
+			h.ServeHTTP(rec, r)
+			writeJSONError(w, rec.status, http.StatusText(rec.status))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// allowMethods answers 405 with an Allow header unless the request uses one of methods;
+// OPTIONS never reaches it because corsMiddleware answers the preflight first
+func allowMethods(h http.HandlerFunc, methods ...string) http.HandlerFunc {
+	allow := strings.Join(append(methods, http.MethodOptions), ", ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !slices.Contains(methods, r.Method) {
+			w.Header().Set("Allow", allow)
+			writeJSONError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		h(w, r)
+	}
+}
+
+// routes returns the mux serving every endpoint; the top-level poll endpoints serve vm, the
+// default poll, and /polls/{id}/... serve any poll in polls
+func (vm *VoteManager) routes(polls *PollRegistry) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/vote", vm.api(allowMethods(vm.rateLimit(vm.voteHandler), http.MethodPost)))
+	mux.Handle("POST /candidates", vm.admin(vm.addCandidateHandler))
+	mux.Handle("DELETE /candidates", vm.admin(vm.removeCandidateHandler))
+	mux.Handle("POST /unvote", vm.admin(vm.unvoteHandler))
+	mux.Handle("/results", vm.api(gzipResponse(etagResponse(allowMethods(vm.resultsHandler, http.MethodGet)))))
+	mux.Handle("GET /results.csv", vm.api(gzipResponse(etagResponse(vm.resultsCSVHandler))))
+	// Long polls outlive HANDLER_TIMEOUT by design, so they skip the timeout middleware
+	mux.Handle("GET /results/poll", vm.longPoll())
+	mux.Handle("GET /leaderboard", vm.api(vm.leaderboardHandler))
+	mux.Handle("/results/range", vm.api(vm.rangeResultsHandler))
+	mux.Handle("/results/turnout", vm.api(vm.turnoutHandler))
+	mux.Handle("GET /rates", vm.api(vm.ratesHandler))
+	mux.Handle("POST /results/batch", vm.api(polls.batchResultsHandler))
+	mux.Handle("GET /polls", vm.api(polls.listPollsHandler))
+	mux.Handle("/polls/{id}/vote", vm.api(allowMethods(vm.rateLimit(polls.route((*VoteManager).voteHandler)), http.MethodPost)))
+	mux.Handle("/polls/{id}/results", vm.api(gzipResponse(etagResponse(allowMethods(polls.route((*VoteManager).resultsHandler), http.MethodGet)))))
+	mux.Handle("/polls/{id}/events", corsMiddleware(vm.cfg.CORS, allowMethods(polls.route((*VoteManager).sseHandler), http.MethodGet)))
+	mux.Handle("/events", corsMiddleware(vm.cfg.CORS, allowMethods(vm.sseHandler, http.MethodGet)))
+	mux.HandleFunc("GET /ws", vm.wsHandler)
+	mux.Handle("/events/watermark", vm.api(vm.watermarkHandler))
+	mux.Handle("/stats", vm.api(vm.statsHandler))
+	mux.Handle("/metrics", polls.metricsHandler())
+	mux.Handle("/config", vm.api(vm.configHandler))
+	mux.Handle("GET /version", vm.api(versionHandler))
+	// Probes skip the middleware so they stay cheap to poll
+	mux.HandleFunc("/readyz", vm.readyzHandler)
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.Handle("GET /admin/results", vm.admin(vm.exactResultsHandler))
+	mux.Handle("POST /reset", vm.admin(vm.resetHandler))
+	mux.Handle("POST /admin/close", vm.admin(vm.closePollHandler))
+	mux.Handle("POST /admin/polls", vm.admin(polls.createPollHandler))
+	mux.Handle("PUT /admin/candidates", vm.admin(vm.replaceCandidatesHandler))
+	mux.Handle("GET /admin/sessions", vm.admin(vm.sessionsHandler))
+	mux.Handle("DELETE /admin/sessions/{id}", vm.admin(vm.revokeSessionHandler))
+	return mux
+}
+
+// publicRoutes returns the read-only mux for the public results port
+func (vm *VoteManager) publicRoutes(polls *PollRegistry) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("/results", vm.api(gzipResponse(etagResponse(allowMethods(vm.resultsHandler, http.MethodGet)))))
+	mux.Handle("GET /results.csv", vm.api(gzipResponse(etagResponse(vm.resultsCSVHandler))))
+	mux.Handle("GET /results/poll", vm.longPoll())
+	mux.Handle("GET /leaderboard", vm.api(vm.leaderboardHandler))
+	mux.Handle("/events", corsMiddleware(vm.cfg.CORS, allowMethods(vm.sseHandler, http.MethodGet)))
+	mux.Handle("/polls/{id}/results", vm.api(gzipResponse(etagResponse(allowMethods(polls.route((*VoteManager).resultsHandler), http.MethodGet)))))
+	mux.Handle("/polls/{id}/events", corsMiddleware(vm.cfg.CORS, allowMethods(polls.route((*VoteManager).sseHandler), http.MethodGet)))
+	return mux
+}
+
+// Handler returns the handler serving every endpoint, or the maintenance handler when
+// MAINTENANCE is set. Wrap it in http.StripPrefix to mount it under a path prefix.
+func (vm *VoteManager) Handler() http.Handler {
+	if vm.cfg.Maintenance {
+		return MaintenanceHandler(vm.cfg)
+	}
+	return vm.serveMux(vm.routes(vm.polls))
+}
+
+// PublicHandler returns the read-only handler for the public results port
+func (vm *VoteManager) PublicHandler() http.Handler {
+	if vm.cfg.Maintenance {
+		return MaintenanceHandler(vm.cfg)
+	}
+	return vm.serveMux(vm.publicRoutes(vm.polls))
+}
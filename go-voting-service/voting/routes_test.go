@@ -0,0 +1,114 @@
+package voting
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestSecurityHeadersOnResults(t *testing.T) {
+	t.Setenv("SECURITY_HEADERS", `{"X-Frame-Options":"DENY","X-Custom":"yes"}`)
+	cfg := testConfig()
+	rec := serve(startManager(t, cfg).Handler(), http.MethodGet, "/results", "")
+	for name, want := range map[string]string{"X-Frame-Options": "DENY", "X-Custom": "yes"} {
+		if got := rec.Header().Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestPublicPortServesResultsButNotVotes(t *testing.T) {
+	vm := startManager(t, testConfig())
+	main, public := newServer(t, vm.Handler()), newServer(t, vm.PublicHandler())
+
+	post := func(base string) int {
+		resp, err := http.Post(base+"/vote", "application/json", strings.NewReader(`{"candidate":"Candidate A"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := post(main.URL); code != http.StatusAccepted {
+		t.Fatalf("vote on the main port: status %d, want 202", code)
+	}
+	if code := post(public.URL); code != http.StatusNotFound {
+		t.Errorf("vote on the public port: status %d, want 404", code)
+	}
+
+	waitFor(t, "the vote", func() bool { return votesFor(vm, "Candidate A") == 1 })
+	resp, err := http.Get(public.URL + "/results")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var results ResultsPayload
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || results.Total != 1 {
+		t.Errorf("public /results: status %d, total %d; want 200, 1", resp.StatusCode, results.Total)
+	}
+}
+
+func TestWrongMethodGets405WithAllow(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	for _, tc := range []struct{ method, target, allow string }{
+		{http.MethodGet, "/vote?candidate=Candidate+A", "POST, OPTIONS"},
+		{http.MethodPut, "/vote", "POST, OPTIONS"},
+		{http.MethodPost, "/results", "GET, OPTIONS"},
+		{http.MethodDelete, "/events", "GET, OPTIONS"},
+	} {
+		rec := serve(h, tc.method, tc.target, "")
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("%s %s: status %d, want 405", tc.method, tc.target, rec.Code)
+			continue
+		}
+		if got := rec.Header().Get("Allow"); got != tc.allow {
+			t.Errorf("%s %s: Allow %q, want %q", tc.method, tc.target, got, tc.allow)
+		}
+	}
+
+	// The GET above must not have counted
+	if got := votesFor(vm, "Candidate A"); got != 0 {
+		t.Errorf("Candidate A has %d votes after a GET, want 0", got)
+	}
+}
+
+func TestPreflightCarriesMaxAge(t *testing.T) {
+	for _, tc := range []struct{ env, want string }{
+		{"", "600"},
+		{"90s", "90"},
+		{"0s", ""},
+	} {
+		if tc.env != "" {
+			t.Setenv("CORS_MAX_AGE", tc.env)
+		}
+		rec := serve(startManager(t, testConfig()).Handler(), http.MethodOptions, "/vote", "",
+			"Origin", "https://example.com", "Access-Control-Request-Method", http.MethodPost)
+		if rec.Code != http.StatusNoContent {
+			t.Fatalf("CORS_MAX_AGE=%q: status %d, want 204", tc.env, rec.Code)
+		}
+		if got := rec.Header().Get("Access-Control-Max-Age"); got != tc.want {
+			t.Errorf("CORS_MAX_AGE=%q: Access-Control-Max-Age %q, want %q", tc.env, got, tc.want)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Methods"); !strings.Contains(got, http.MethodPost) {
+			t.Errorf("Access-Control-Allow-Methods %q does not allow POST", got)
+		}
+	}
+}
+
+func TestProbesSkipCORSOnOptions(t *testing.T) {
+	h := startManager(t, testConfig()).Handler()
+	for _, path := range []string{"/healthz", "/readyz"} {
+		rec := serve(h, http.MethodOptions, path, "", "Origin", "https://example.com")
+		if got := rec.Header().Get("Access-Control-Allow-Methods"); got != "" {
+			t.Errorf("OPTIONS %s went through CORS: Access-Control-Allow-Methods %q", path, got)
+		}
+		if rec.Code == http.StatusNoContent {
+			t.Errorf("OPTIONS %s got the CORS preflight answer", path)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package voting
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Pre-vote modes control how votes cast before OpenAt are handled
+const (
+	preVoteReject = "reject"
+	preVoteQueue  = "queue"
+)
+
+var (
+	errPollNotOpen   = errors.New("poll is not open yet")
+	errPollClosed    = errors.New("poll is closed")
+	errPreVoteFull   = errors.New("pre-vote queue is full")
+	errPreVoteQueued = errors.New("vote queued until the poll opens")
+	errBusy          = errors.New("server is busy, try again later")
+	errShedding      = errors.New("server is under load, retry shortly")
+)
+
+// eventClosed is the SSE event type sent once when the poll closes
+const eventClosed = "closed"
+
+// PollClosed is the payload of a closed event
+type PollClosed struct {
+	ClosedAt time.Time `json:"closedAt"`
+
+	EventMeta
+}
+
+// pollState tracks whether the poll is open or closed and holds votes queued before it opened.
+// Vote admission holds the read lock across the enqueue so state changes never interleave with it.
+type pollState struct {
+	mu      sync.RWMutex
+	opened  bool
+	closed  bool
+	stopped bool       // set by Stop before voteChannel is closed
+	queueMu sync.Mutex // guards queue while admitters share the read lock
+	queue   []vote
+	timer   Timer
+	closer  Timer // fires ClosePoll at the deadline
+}
+
+// scheduleOpen arms the opening timer, or marks the poll open when no OpenAt is set or it has passed
+func (vm *VoteManager) scheduleOpen() {
+	wait := vm.cfg.OpenAt.Sub(vm.clock.Now())
+	if vm.cfg.OpenAt.IsZero() || wait <= 0 {
+		vm.state.mu.Lock()
+		vm.state.opened = true
+		vm.state.mu.Unlock()
+		return
+	}
+	slog.Info("Poll scheduled to open", "open_at", vm.cfg.OpenAt, "pre_vote_mode", vm.cfg.PreVoteMode)
+	vm.state.timer = vm.clock.AfterFunc(wait, vm.open)
+}
+
+// scheduleClose arms a single timer that closes the poll at Deadline, closing it right away
+// if the deadline has already passed
+func (vm *VoteManager) scheduleClose() {
+	if vm.cfg.Deadline.IsZero() {
+		return
+	}
+	wait := vm.cfg.Deadline.Sub(vm.clock.Now())
+	if wait <= 0 {
+		vm.ClosePoll()
+		return
+	}
+	slog.Info("Poll scheduled to close", "deadline", vm.cfg.Deadline)
+	vm.state.closer = vm.clock.AfterFunc(wait, vm.ClosePoll)
+}
+
+// open marks the poll open and applies queued pre-votes in one step, broadcasting the resulting snapshot
+func (vm *VoteManager) open() {
+	vm.state.mu.Lock()
+	vm.state.opened = true
+	queued := vm.state.queue
+	vm.state.queue = nil
+	vm.state.mu.Unlock()
+
+	slog.Info("Poll opened", "queued_votes", len(queued))
+	vm.do(func() {
+		// Like processVote, drop votes queued before a reset and resolve weights, which may call
+		// out to the Reputation hook, before taking the lock readers wait on
+		epoch := vm.epoch.Load()
+		weights := make([]int, len(queued))
+		for i, v := range queued {
+			if v.epoch == epoch {
+				weights[i] = v.weight * vm.voteWeight(v.identity)
+			}
+		}
+		now := vm.clock.Now()
+		counted := make([]bool, len(queued))
+		retracted := make([]*Candidate, len(queued))
+		taken := make([]int, len(queued))
+		vm.mu.Lock()
+		for i, v := range queued {
+			if v.epoch != epoch {
+				continue
+			}
+			if candidate, exists := vm.candidates[v.candidate]; exists {
+				vm.countVote(candidate, now, weights[i])
+				retracted[i], taken[i] = vm.retractVote(v, now, weights[i])
+				counted[i] = true
+			}
+		}
+		vm.mu.Unlock()
+		for i, v := range queued {
+			switch {
+			case v.epoch != epoch:
+				slog.Info("Discarding queued vote admitted before a reset", "candidate", v.candidate)
+			case !counted[i]:
+				slog.Warn("Dropping queued vote for unknown candidate", "candidate", v.candidate)
+			default:
+				vm.history.add(now, v.candidate, weights[i])
+				vm.metrics.votes.WithLabelValues(v.candidate).Add(float64(weights[i]))
+				vm.auditVote(v, now, weights[i])
+				if retracted[i] != nil {
+					vm.auditRetraction(v, retracted[i].Name, now, taken[i])
+				}
+				vm.notifyVoter(v)
+			}
+		}
+		vm.notifyClients(eventUpdate)
+		for _, candidate := range vm.candidates {
+			vm.checkMilestone(candidate)
+		}
+	})
+}
+
+// ClosePoll stops accepting votes, counts every vote already accepted into voteChannel,
+// and then broadcasts the final snapshot followed by a closed event so dashboards can freeze
+func (vm *VoteManager) ClosePoll() {
+	vm.state.mu.Lock()
+	if vm.state.closed {
+		vm.state.mu.Unlock()
+		return
+	}
+	vm.state.closed = true
+	vm.state.mu.Unlock()
+
+	// do drains the buffered votes before running, so no 202'd vote is lost
+	vm.do(func() {
+		vm.notifyClients(eventSnapshot)
+		vm.broadcast(eventClosed, &PollClosed{ClosedAt: vm.clock.Now()})
+	})
+	slog.Info("Poll closed")
+}
+
+// closePollHandler closes the poll and returns the final results
+func (vm *VoteManager) closePollHandler(w http.ResponseWriter, r *http.Request) {
+	vm.ClosePoll()
+	writeJSON(w, http.StatusOK, vm.snapshot())
+}
+
+// enqueueVote admits a vote according to the poll state and hands it to the processing goroutine.
+// Before opening it rejects or queues the vote per PreVoteMode; errPreVoteQueued means it was accepted.
+func (vm *VoteManager) enqueueVote(v vote) error {
+	vm.state.mu.RLock()
+	defer vm.state.mu.RUnlock()
+
+	if vm.state.stopped {
+		return errStopped
+	}
+	if vm.state.closed {
+		return errPollClosed
+	}
+	if !vm.state.opened {
+		return vm.queuePreVote(v)
+	}
+	// Shed load before the buffer is completely full once it passes the soft threshold
+	if vm.cfg.ShedThreshold > 0 && float64(len(vm.voteChannel)) >= vm.cfg.ShedThreshold*float64(cap(vm.voteChannel)) {
+		return errShedding
+	}
+	select {
+	case vm.voteChannel <- v:
+		return nil
+	default:
+		return errBusy
+	}
+}
+
+// queuePreVote buffers a vote cast before the poll opens, if PreVoteMode allows it
+func (vm *VoteManager) queuePreVote(v vote) error {
+	if vm.cfg.PreVoteMode != preVoteQueue {
+		return errPollNotOpen
+	}
+	vm.state.queueMu.Lock()
+	defer vm.state.queueMu.Unlock()
+	if len(vm.state.queue) >= vm.cfg.PreVoteCap {
+		return errPreVoteFull
+	}
+	vm.state.queue = append(vm.state.queue, v)
+	return errPreVoteQueued
+}
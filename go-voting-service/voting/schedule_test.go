@@ -0,0 +1,174 @@
+package voting
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestVotesBeforeOpenAreRejected(t *testing.T) {
+	clock := newFakeClock()
+	cfg := testConfig()
+	cfg.OpenAt = clock.Now().Add(time.Hour)
+	cfg.PreVoteMode = preVoteReject
+	vm := startManagerWithClock(t, cfg, clock)
+	h := vm.Handler()
+
+	if rec := postVote(h, "Candidate A"); rec.Code != http.StatusLocked {
+		t.Fatalf("vote before open: status %d, want 423", rec.Code)
+	}
+
+	clock.Advance(time.Hour)
+	if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+		t.Fatalf("vote after open: status %d, body %s", rec.Code, rec.Body)
+	}
+	waitFor(t, "the vote after opening", func() bool { return votesFor(vm, "Candidate A") == 1 })
+}
+
+func TestVotesBeforeOpenAreQueuedUntilOpen(t *testing.T) {
+	clock := newFakeClock()
+	cfg := testConfig()
+	cfg.OpenAt = clock.Now().Add(time.Hour)
+	cfg.PreVoteMode = preVoteQueue
+	vm := startManagerWithClock(t, cfg, clock)
+	h := vm.Handler()
+
+	for range 2 {
+		if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+			t.Fatalf("queued vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	if got := votesFor(vm, "Candidate A"); got != 0 {
+		t.Fatalf("Candidate A has %d votes before opening, want 0", got)
+	}
+
+	clock.Advance(time.Hour - time.Second)
+	if got := votesFor(vm, "Candidate A"); got != 0 {
+		t.Fatalf("Candidate A has %d votes a second before opening, want 0", got)
+	}
+	clock.Advance(time.Second)
+	waitFor(t, "queued votes", func() bool { return votesFor(vm, "Candidate A") == 2 })
+}
+
+func TestClosePollCountsBufferedVotes(t *testing.T) {
+	const buffered = 20
+	cfg := testConfig()
+	cfg.VoteBuffer = buffered
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+
+	release := holdProcessing(vm)
+	for range buffered {
+		if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	if n := len(vm.voteChannel); n != buffered {
+		t.Fatalf("%d votes buffered, want %d", n, buffered)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		vm.ClosePoll()
+		close(closed)
+	}()
+	waitFor(t, "the poll to close", func() bool {
+		vm.state.mu.RLock()
+		defer vm.state.mu.RUnlock()
+		return vm.state.closed
+	})
+	if rec := postVote(h, "Candidate A"); rec.Code != http.StatusLocked {
+		t.Errorf("vote after close: status %d, want 423", rec.Code)
+	}
+	release()
+	<-closed
+
+	if got := votesFor(vm, "Candidate A"); got != buffered {
+		t.Errorf("final tally %d, want the %d buffered votes", got, buffered)
+	}
+}
+
+func TestVotesAreShedPastThreshold(t *testing.T) {
+	cfg := testConfig()
+	cfg.VoteBuffer = 10
+	cfg.ShedThreshold = 0.5
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+
+	release := holdProcessing(vm)
+	defer release()
+	for i := range 5 {
+		if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote %d below the threshold: status %d, body %s", i+1, rec.Code, rec.Body)
+		}
+	}
+	rec := postVote(h, "Candidate A")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("vote at the threshold: status %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("shed vote has no Retry-After")
+	}
+	if n := len(vm.voteChannel); n != 5 {
+		t.Errorf("%d votes buffered, want 5", n)
+	}
+}
+
+func TestOpeningDoesNotBlockReadersOnReputation(t *testing.T) {
+	clock := newFakeClock()
+	cfg := testConfig()
+	cfg.OpenAt = clock.Now().Add(time.Hour)
+	cfg.PreVoteMode = preVoteQueue
+	vm := startManagerWithClock(t, cfg, clock)
+	h := vm.Handler()
+
+	entered, unblock := make(chan struct{}), make(chan struct{})
+	vm.Reputation = func(string) (int, error) {
+		close(entered)
+		<-unblock
+		return 2, nil
+	}
+	if rec := serve(h, http.MethodPost, "/vote", `{"candidate":"Candidate A","voter":"alice"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("queued vote: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	go clock.Advance(time.Hour)
+	<-entered
+	read := make(chan int, 1)
+	go func() { read <- serve(h, http.MethodGet, "/results", "").Code }()
+	select {
+	case code := <-read:
+		if code != http.StatusOK {
+			t.Errorf("/results during the lookup: status %d, want 200", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("/results blocked while opening waited on the reputation lookup")
+	}
+	close(unblock)
+	waitFor(t, "the queued vote", func() bool { return votesFor(vm, "Candidate A") == 2 })
+}
+
+func TestResetDiscardsQueuedVotes(t *testing.T) {
+	clock := newFakeClock()
+	cfg := testConfig()
+	cfg.OpenAt = clock.Now().Add(time.Hour)
+	cfg.PreVoteMode = preVoteQueue
+	vm := startManagerWithClock(t, cfg, clock)
+	h := vm.Handler()
+
+	if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+		t.Fatalf("queued vote: status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := serve(h, http.MethodPost, "/reset", "", adminHeader...); rec.Code != http.StatusOK {
+		t.Fatalf("reset: status %d, body %s", rec.Code, rec.Body)
+	}
+	if rec := postVote(h, "Candidate B"); rec.Code != http.StatusAccepted {
+		t.Fatalf("queued vote: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	clock.Advance(time.Hour)
+	waitFor(t, "the vote queued after the reset", func() bool { return votesFor(vm, "Candidate B") == 1 })
+	if got := votesFor(vm, "Candidate A"); got != 0 {
+		t.Errorf("Candidate A has %d votes; the vote queued before the reset survived it", got)
+	}
+}
@@ -0,0 +1,122 @@
+package voting
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+)
+
+// CheckResult is the outcome of one startup self-check
+type CheckResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// validate reports configuration values that would only fail later at runtime
+func (c Config) validate() error {
+	var errs []error
+	if c.PingInterval <= 0 {
+		errs = append(errs, errors.New("SSE_PING_INTERVAL must be positive"))
+	}
+	if c.HandlerTimeout <= 0 {
+		errs = append(errs, errors.New("HANDLER_TIMEOUT must be positive"))
+	}
+	if c.PreVoteMode == preVoteQueue && c.PreVoteCap == 0 {
+		errs = append(errs, errors.New("PRE_VOTE_CAP must be positive in queue mode"))
+	}
+	if c.AutoCreateCandidates && c.MaxCandidates == 0 {
+		errs = append(errs, errors.New("MAX_CANDIDATES must be positive when AUTO_CREATE_CANDIDATES is on"))
+	}
+	if c.MaxVoteWeight < 1 {
+		errs = append(errs, errors.New("MAX_VOTE_WEIGHT must be at least 1"))
+	}
+	if c.HistorySize == 0 {
+		errs = append(errs, errors.New("HISTORY_SIZE must be positive"))
+	}
+	if c.DataFile != "" && c.SaveInterval <= 0 {
+		errs = append(errs, errors.New("SAVE_INTERVAL must be positive when DATA_FILE is set"))
+	}
+	if c.AuditLogFile != "" && c.AuditFlushInterval <= 0 {
+		errs = append(errs, errors.New("AUDIT_FLUSH_INTERVAL must be positive when AUDIT_LOG_FILE is set"))
+	}
+	if c.ExportURL != "" {
+		if c.ExportInterval <= 0 {
+			errs = append(errs, errors.New("EXPORT_INTERVAL must be positive when EXPORT_URL is set"))
+		}
+		if u, err := url.Parse(c.ExportURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, errors.New("EXPORT_URL must be an http or https URL"))
+		}
+	}
+	if !c.Deadline.IsZero() && !c.OpenAt.IsZero() && !c.Deadline.After(c.OpenAt) {
+		errs = append(errs, errors.New("VOTE_DEADLINE must be after OPEN_AT"))
+	}
+	if c.SyncVotes && (c.SyncVoteTimeout <= 0 || c.SyncVoteTimeout >= c.HandlerTimeout) {
+		errs = append(errs, errors.New("SYNC_VOTE_TIMEOUT must be positive and below HANDLER_TIMEOUT when SYNC_VOTES is on"))
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+	if c.CORS.AllowCredentials && slices.Contains(c.CORS.Origins, "*") {
+		errs = append(errs, errors.New("CORS_ALLOW_CREDENTIALS requires explicit CORS_ORIGINS, not *"))
+	}
+	return errors.Join(errs...)
+}
+
+// SelfCheck verifies the configuration and that every listen address can be bound.
+// It returns every check's result and an aggregated error naming all failures.
+func SelfCheck(cfg Config, addrs ...string) ([]CheckResult, error) {
+	var results []CheckResult
+	var errs []error
+	record := func(name string, err error) {
+		result := CheckResult{Name: name, OK: err == nil}
+		if err != nil {
+			result.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+		results = append(results, result)
+	}
+
+	record("config", cfg.validate())
+	if cfg.DataFile != "" && !cfg.ReadOnly {
+		record("data dir", checkDataDir(cfg.DataFile))
+	}
+	if cfg.CandidatesFile != "" {
+		_, err := loadCandidatesFile(cfg.CandidatesFile, cfg.MaxCandidates)
+		record("candidates file", err)
+	}
+	if cfg.AuditLogFile != "" {
+		record("audit log dir", checkDataDir(cfg.AuditLogFile))
+	}
+	if cfg.TLSEnabled() {
+		_, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		record("tls keypair", err)
+	}
+	for _, addr := range addrs {
+		ln, err := net.Listen("tcp", addr)
+		if err == nil {
+			ln.Close()
+		}
+		record("listen "+addr, err)
+	}
+	return results, errors.Join(errs...)
+}
+
+// readyzHandler reports the startup self-check results. It answers 503 if any failed, before
+// Start has brought vote processing up, and once Stop has begun.
+func (vm *VoteManager) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	if !vm.ready.Load() {
+		status = http.StatusServiceUnavailable
+	}
+	for _, check := range vm.Checks {
+		if !check.OK {
+			status = http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, status, map[string]any{"ready": status == http.StatusOK, "checks": vm.Checks})
+}
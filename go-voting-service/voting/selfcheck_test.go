@@ -0,0 +1,36 @@
+package voting
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSelfCheckFailsOnUnwritableStore(t *testing.T) {
+	// A regular file where the data directory should be cannot be written into, even as root
+	notADir := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(notADir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := testConfig()
+	cfg.DataFile = filepath.Join(notADir, "votes.json")
+
+	results, err := SelfCheck(cfg)
+	if err == nil {
+		t.Fatal("SelfCheck passed with an unwritable data file")
+	}
+	if !strings.Contains(err.Error(), "data dir") || !strings.Contains(err.Error(), notADir) {
+		t.Errorf("error %q does not name the data dir check and path", err)
+	}
+	for _, r := range results {
+		if r.Name == "data dir" && r.OK {
+			t.Error("data dir check reported OK")
+		}
+	}
+
+	cfg.DataFile = filepath.Join(t.TempDir(), "votes.json")
+	if _, err := SelfCheck(cfg); err != nil {
+		t.Errorf("SelfCheck with a writable data file: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package voting
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Session tracks a single SSE connection for operators
+type Session struct {
+	ID          string
+	RemoteIP    string
+	ConnectedAt time.Time
+	lastEvent   atomic.Int64 // unix nanoseconds of the last event written
+}
+
+// SessionInfo is the JSON view of a Session returned by /admin/sessions
+type SessionInfo struct {
+	ID          string     `json:"id"`
+	RemoteIP    string     `json:"remoteIp"`
+	ConnectedAt time.Time  `json:"connectedAt"`
+	LastEventAt *time.Time `json:"lastEventAt"`
+}
+
+// newSession creates a session for a client connecting from ip
+func newSession(ip string, now time.Time) *Session {
+	return &Session{ID: newID(), RemoteIP: ip, ConnectedAt: now}
+}
+
+// newID returns a random 16-character hex identifier
+func newID() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// touch records that an event was just delivered
+func (s *Session) touch(now time.Time) {
+	s.lastEvent.Store(now.UnixNano())
+}
+
+// info returns the JSON view of the session
+func (s *Session) info() SessionInfo {
+	info := SessionInfo{ID: s.ID, RemoteIP: s.RemoteIP, ConnectedAt: s.ConnectedAt}
+	if ns := s.lastEvent.Load(); ns != 0 {
+		last := time.Unix(0, ns)
+		info.LastEventAt = &last
+	}
+	return info
+}
+
+// Sessions returns the active SSE sessions
+func (vm *VoteManager) Sessions() []SessionInfo {
+	reply, _ := vm.clientRequest(cliRequest{action: "list", reply: make(chan cliReply, 1)})
+	return reply.sessions
+}
+
+// RevokeSession disconnects the SSE session with the given ID, reporting whether it existed
+func (vm *VoteManager) RevokeSession(id string) bool {
+	reply, _ := vm.clientRequest(cliRequest{action: "revoke", sessionID: id, reply: make(chan cliReply, 1)})
+	return reply.found
+}
+
+// sessionsHandler lists active SSE sessions
+func (vm *VoteManager) sessionsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, vm.Sessions())
+}
+
+// revokeSessionHandler forcibly disconnects an SSE session
+func (vm *VoteManager) revokeSessionHandler(w http.ResponseWriter, r *http.Request) {
+	if !vm.RevokeSession(r.PathValue("id")) {
+		writeJSONError(w, http.StatusNotFound, "Session not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
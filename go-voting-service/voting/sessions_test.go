@@ -0,0 +1,64 @@
+package voting
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestRevokeSessionEndsStream(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	stream := openStream(t, newServer(t, h).URL+"/events")
+	stream.nextOf(t, eventSnapshot)
+
+	var sessions []SessionInfo
+	decodeJSON(t, serve(h, http.MethodGet, "/admin/sessions", "", adminHeader...), &sessions)
+	if len(sessions) != 1 {
+		t.Fatalf("%d sessions listed, want 1", len(sessions))
+	}
+
+	if rec := serve(h, http.MethodDelete, "/admin/sessions/"+sessions[0].ID, "", adminHeader...); rec.Code != http.StatusNoContent {
+		t.Fatalf("revoke: status %d, body %s", rec.Code, rec.Body)
+	}
+	timeout := time.After(5 * time.Second)
+	for ended := false; !ended; {
+		select {
+		case _, ok := <-stream.events:
+			ended = !ok
+		case <-timeout:
+			t.Fatal("stream still open after revoking its session")
+		}
+	}
+	waitFor(t, "the session to go", func() bool { return len(vm.Sessions()) == 0 })
+
+	if rec := serve(h, http.MethodDelete, "/admin/sessions/"+sessions[0].ID, "", adminHeader...); rec.Code != http.StatusNotFound {
+		t.Errorf("revoking again: status %d, want 404", rec.Code)
+	}
+}
+
+func TestSessionReportsForwardedClientIP(t *testing.T) {
+	cfg := testConfig()
+	cfg.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, newServer(t, h).URL+"/events", nil)
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	waitFor(t, "the session to register", func() bool { return len(vm.Sessions()) == 1 })
+
+	var sessions []SessionInfo
+	decodeJSON(t, serve(h, http.MethodGet, "/admin/sessions", "", adminHeader...), &sessions)
+	if len(sessions) != 1 || sessions[0].RemoteIP != "203.0.113.7" {
+		t.Errorf("sessions %+v, want one from 203.0.113.7", sessions)
+	}
+}
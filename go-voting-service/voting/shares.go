@@ -0,0 +1,69 @@
+package voting
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// totalBasisPoints is a 100% share expressed in basis points
+const totalBasisPoints = 10000
+
+// applyPercentages sets each candidate's share of all votes as a percentage rounded to two
+// decimals; every share is 0 before the first vote, never NaN
+func applyPercentages(candidates []*Candidate) {
+	total := 0
+	for _, c := range candidates {
+		total += c.Votes
+	}
+	for _, c := range candidates {
+		c.Percentage = 0
+		if total > 0 {
+			c.Percentage = math.Round(float64(c.Votes)/float64(total)*10000) / 100
+		}
+	}
+}
+
+// applyBasisPoints sets each candidate's ShareBps using the largest-remainder method,
+// so the shares are exact integers that always sum to 10000 (or are all 0 with no votes)
+func applyBasisPoints(candidates []*Candidate) {
+	total := 0
+	for _, c := range candidates {
+		total += c.Votes
+	}
+
+	type share struct {
+		c         *Candidate
+		bps       int
+		remainder int
+	}
+	shares := make([]share, len(candidates))
+	assigned := 0
+	for i, c := range candidates {
+		shares[i] = share{c: c}
+		if total > 0 {
+			shares[i].bps = c.Votes * totalBasisPoints / total
+			shares[i].remainder = c.Votes * totalBasisPoints % total
+		}
+		assigned += shares[i].bps
+	}
+
+	if total > 0 {
+		// Hand the leftover points to the largest remainders; the stable sort keeps ties in input order
+		order := make([]int, len(shares))
+		for i := range order {
+			order[i] = i
+		}
+		slices.SortStableFunc(order, func(a, b int) int {
+			return cmp.Compare(shares[b].remainder, shares[a].remainder)
+		})
+		for _, i := range order[:totalBasisPoints-assigned] {
+			shares[i].bps++
+		}
+	}
+
+	for _, s := range shares {
+		bps := s.bps
+		s.c.ShareBps = &bps
+	}
+}
@@ -0,0 +1,40 @@
+package voting
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBasisPointsSumAndMatchPercentages(t *testing.T) {
+	for _, votes := range [][]int{
+		{1, 1, 1},
+		{2, 1},
+		{7, 3, 1, 1, 0},
+		{1, 0},
+		{0, 0},
+	} {
+		candidates := make([]*Candidate, len(votes))
+		for i, n := range votes {
+			candidates[i] = &Candidate{Name: string(rune('A' + i)), Votes: n}
+		}
+		applyPercentages(candidates)
+		applyBasisPoints(candidates)
+
+		sum, total := 0, 0
+		for _, c := range candidates {
+			sum += *c.ShareBps
+			total += c.Votes
+			// One basis point of largest-remainder rounding, plus the percentage's own rounding
+			if diff := math.Abs(float64(*c.ShareBps)/100 - c.Percentage); diff > 0.01+1e-9 {
+				t.Errorf("votes %v: %s has %d bps but %.2f%%", votes, c.Name, *c.ShareBps, c.Percentage)
+			}
+		}
+		want := totalBasisPoints
+		if total == 0 {
+			want = 0
+		}
+		if sum != want {
+			t.Errorf("votes %v: basis points sum to %d, want %d", votes, sum, want)
+		}
+	}
+}
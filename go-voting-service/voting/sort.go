@@ -0,0 +1,73 @@
+package voting
+
+import (
+	"cmp"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"strings"
+)
+
+// Sort orders accepted by the ?sort query parameter; a leading "-" means descending
+const (
+	sortByName      = "name"
+	sortByNameDesc  = "-name"
+	sortByVotes     = "votes"
+	sortByVotesDesc = "-votes"
+)
+
+// sortAliases maps the default and the older desc/asc spellings to their orders
+var sortAliases = map[string]string{
+	"":     sortByVotesDesc,
+	"desc": sortByVotesDesc,
+	"asc":  sortByVotes,
+}
+
+var errInvalidSort = errors.New("sort must be one of votes, -votes, name or -name")
+
+// canonicalSort resolves mode, including the default and aliases, to one of the sort orders
+func canonicalSort(mode string) (string, error) {
+	if alias, ok := sortAliases[mode]; ok {
+		return alias, nil
+	}
+	switch mode {
+	case sortByName, sortByNameDesc, sortByVotes, sortByVotesDesc:
+		return mode, nil
+	}
+	return "", errInvalidSort
+}
+
+// sortCandidates orders candidates in place by mode, breaking vote ties by name so the order is
+// always deterministic. An empty mode sorts by descending votes.
+func sortCandidates(candidates []*Candidate, mode string) error {
+	mode, err := canonicalSort(mode)
+	if err != nil {
+		return err
+	}
+	byName := func(a, b *Candidate) int { return strings.Compare(a.Name, b.Name) }
+
+	switch mode {
+	case sortByName:
+		slices.SortFunc(candidates, byName)
+	case sortByNameDesc:
+		slices.SortFunc(candidates, func(a, b *Candidate) int { return byName(b, a) })
+	case sortByVotesDesc:
+		slices.SortFunc(candidates, func(a, b *Candidate) int {
+			return cmp.Or(cmp.Compare(b.Votes, a.Votes), byName(a, b))
+		})
+	case sortByVotes:
+		slices.SortFunc(candidates, func(a, b *Candidate) int {
+			return cmp.Or(cmp.Compare(a.Votes, b.Votes), byName(a, b))
+		})
+	}
+	return nil
+}
+
+// shuffleCandidates randomizes the order in place with a fresh seed per call, so each
+// response shows an independent order and no candidate is anchored at the top
+func shuffleCandidates(candidates []*Candidate) {
+	r := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	r.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+}
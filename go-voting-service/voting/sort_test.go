@@ -0,0 +1,56 @@
+package voting
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestShuffledResultsKeepSetAndCounts(t *testing.T) {
+	cfg := testConfig()
+	cfg.ResultsShuffle = true
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+	for i := range 4 {
+		if err := vm.AddCandidate(fmt.Sprintf("Extra %d", i), CandidateInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rec := postVote(h, "Extra 2"); rec.Code != http.StatusAccepted {
+		t.Fatalf("vote: status %d", rec.Code)
+	}
+	waitFor(t, "the vote", func() bool { return votesFor(vm, "Extra 2") == 1 })
+
+	orders := make(map[string]bool)
+	var counts map[string]int
+	for range 20 {
+		var results ResultsPayload
+		decodeJSON(t, serve(h, http.MethodGet, "/results", ""), &results)
+		got := make(map[string]int)
+		for _, c := range results.Candidates {
+			got[c.Name] = c.Votes
+		}
+		if counts == nil {
+			counts = got
+		} else if !maps.Equal(got, counts) {
+			t.Fatalf("shuffled results %v differ from %v", got, counts)
+		}
+		orders[fmt.Sprint(names(results.Candidates))] = true
+	}
+	if len(counts) != 6 || counts["Extra 2"] != 1 {
+		t.Errorf("results %v, want six candidates with Extra 2 at 1", counts)
+	}
+	// Twenty identical orders of six candidates would happen by chance about once in 10^54
+	if len(orders) < 2 {
+		t.Errorf("the same order on every request: %v", orders)
+	}
+
+	// An explicit ?sort still wins over shuffling
+	var sorted ResultsPayload
+	decodeJSON(t, serve(h, http.MethodGet, "/results?sort=name", ""), &sorted)
+	if got := names(sorted.Candidates); !slices.IsSorted(got) {
+		t.Errorf("?sort=name order %v is not sorted", got)
+	}
+}
@@ -0,0 +1,77 @@
+package voting
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// SSE field names accepted in SSE_FIELD_ORDER
+const (
+	sseFieldID    = "id"
+	sseFieldEvent = "event"
+	sseFieldData  = "data"
+)
+
+// defaultSSEFieldOrder is the order fields are written in unless configured otherwise
+var defaultSSEFieldOrder = []string{sseFieldID, sseFieldEvent, sseFieldData}
+
+// Results event types; every one carries the full ResultsPayload so clients can replace their
+// state with any of them, and the type says why it was sent
+const (
+	eventSnapshot         = "snapshot"          // the full state, on connect or after a wholesale change
+	eventUpdate           = "update"            // counts changed after votes
+	eventCandidateAdded   = "candidate_added"   // a candidate joined the poll
+	eventCandidateRemoved = "candidate_removed" // a candidate left the poll
+)
+
+// sseFullRetry is the Retry-After sent when MaxSSEClients are already connected
+const sseFullRetry = 5 * time.Second
+
+var errTooManyClients = errors.New("too many connected clients")
+
+// eventClose tells clients the server is going away and they should stop reconnecting
+const eventClose = "close"
+
+// shutdownEvent is sent to every client before its stream is closed on shutdown
+var shutdownEvent = sseEvent{Event: eventClose, Data: `{"reason":"shutdown"}`}
+
+// sseEvent is a single Server-Sent Event; empty ID and Event fields are omitted
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+// writeRetry sets how many milliseconds the client waits before reconnecting
+func writeRetry(w io.Writer, ms int) error {
+	_, err := fmt.Fprintf(w, "retry: %d\n\n", ms)
+	return err
+}
+
+// writeEvent writes ev to w with its fields in the given order, terminated by a blank line
+func writeEvent(w io.Writer, ev sseEvent, order []string) error {
+	var b strings.Builder
+	for _, field := range order {
+		switch field {
+		case sseFieldID:
+			if ev.ID != "" {
+				fmt.Fprintf(&b, "id: %s\n", ev.ID)
+			}
+		case sseFieldEvent:
+			if ev.Event != "" {
+				fmt.Fprintf(&b, "event: %s\n", ev.Event)
+			}
+		case sseFieldData:
+			// Each line of a multi-line payload needs its own data field
+			for _, line := range strings.Split(ev.Data, "\n") {
+				fmt.Fprintf(&b, "data: %s\n", line)
+			}
+		}
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,405 @@
+package voting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCompositeUpdateCarriesDeltaAndTotal(t *testing.T) {
+	vm := startManager(t, testConfig())
+	ts := newServer(t, vm.Handler())
+	stream := openStream(t, ts.URL+"/events?composite=true")
+	stream.nextOf(t, eventSnapshot)
+
+	if rec := postVote(vm.Handler(), "Candidate A"); rec.Code != http.StatusAccepted {
+		t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+	}
+	update := stream.nextOf(t, eventUpdate).results(t)
+	if update.Total != 1 {
+		t.Errorf("update total %d, want 1", update.Total)
+	}
+	if len(update.Delta) != 1 || update.Delta[0].Name != "Candidate A" || update.Delta[0].Votes != 1 {
+		t.Errorf("update delta %+v, want only Candidate A at 1", update.Delta)
+	}
+	if len(update.Candidates) != 2 {
+		t.Errorf("update lists %d candidates, want the full 2", len(update.Candidates))
+	}
+}
+
+func TestCompositeDeltaAfterQuietPeriod(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+
+	// Nobody is listening, so these broadcasts are skipped
+	for range 2 {
+		if rec := postVote(h, "Candidate B"); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "the quiet votes", func() bool { return votesFor(vm, "Candidate B") == 2 })
+
+	stream := openStream(t, newServer(t, h).URL+"/events?composite=true")
+	stream.nextOf(t, eventSnapshot)
+	if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+		t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+	}
+	update := stream.nextOf(t, eventUpdate).results(t)
+	if got := names(update.Delta); !slices.Equal(got, []string{"Candidate A"}) {
+		t.Errorf("first delta after the quiet period lists %v, want only Candidate A", got)
+	}
+}
+
+// names lists the candidates' names in order
+func names(candidates []*Candidate) []string {
+	out := make([]string, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.Name
+	}
+	return out
+}
+
+func TestSnapshotOrderMatchesResults(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	for _, name := range []string{"Candidate C", "Candidate D"} {
+		if err := vm.AddCandidate(name, CandidateInfo{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"Candidate D", "Candidate B", "Candidate B"} {
+		if rec := postVote(h, name); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "votes", func() bool { return votesFor(vm, "Candidate B") == 2 && votesFor(vm, "Candidate D") == 1 })
+
+	var results ResultsPayload
+	decodeJSON(t, serve(h, http.MethodGet, "/results", ""), &results)
+	snapshot := openStream(t, newServer(t, h).URL+"/events").nextOf(t, eventSnapshot).results(t)
+
+	want := names(results.Candidates)
+	if got := names(snapshot.Candidates); !slices.Equal(got, want) {
+		t.Errorf("snapshot order %v, /results order %v", got, want)
+	}
+}
+
+func TestWatermarkAdvancesWithBroadcasts(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	watermark := func() uint64 {
+		var body struct{ Seq uint64 }
+		decodeJSON(t, serve(h, http.MethodGet, "/events/watermark", ""), &body)
+		return body.Seq
+	}
+
+	stream := openStream(t, newServer(t, h).URL+"/events")
+	stream.nextOf(t, eventSnapshot)
+	start := watermark()
+
+	const votes = 3
+	for range votes {
+		if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+		// Wait for each broadcast so none are coalesced
+		stream.nextOf(t, eventUpdate)
+	}
+	if got := watermark(); got != start+votes {
+		t.Errorf("watermark %d after %d broadcasts from %d, want %d", got, votes, start, start+votes)
+	}
+}
+
+// flushRecorder is a concurrency-safe ResponseWriter for driving sseHandler directly; it
+// records what had been written at each Flush
+type flushRecorder struct {
+	mu      sync.Mutex
+	header  http.Header
+	body    strings.Builder
+	flushes []string
+}
+
+func newFlushRecorder() *flushRecorder { return &flushRecorder{header: make(http.Header)} }
+
+func (f *flushRecorder) Header() http.Header { return f.header }
+
+func (f *flushRecorder) WriteHeader(int) {}
+
+func (f *flushRecorder) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.body.Write(p)
+}
+
+func (f *flushRecorder) Flush() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.flushes = append(f.flushes, f.body.String())
+}
+
+// written returns everything written so far and the content as of each flush
+func (f *flushRecorder) written() (string, []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.body.String(), slices.Clone(f.flushes)
+}
+
+// serveStream runs h for an SSE request to target on its own goroutine until the test ends or
+// disconnect is called, which returns once the handler has
+func serveStream(t *testing.T, h http.Handler, target string) (rec *flushRecorder, disconnect func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	rec = newFlushRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil).WithContext(ctx))
+	}()
+	disconnect = func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(disconnect)
+	return rec, disconnect
+}
+
+func TestFlushDelayBatchesEarlyUpdatesWithSnapshot(t *testing.T) {
+	clock := newFakeClock()
+	cfg := testConfig()
+	cfg.SSEFlushDelay = time.Second
+	vm := startManagerWithClock(t, cfg, clock)
+	h := vm.Handler()
+
+	rec, _ := serveStream(t, h, "/events")
+	waitFor(t, "the flush delay timer", func() bool { return clock.Pending() > 0 })
+
+	if code := postVote(h, "Candidate A").Code; code != http.StatusAccepted {
+		t.Fatalf("vote: status %d", code)
+	}
+	waitFor(t, "the update to be written", func() bool {
+		body, _ := rec.written()
+		return strings.Contains(body, "event: update")
+	})
+	if _, flushes := rec.written(); len(flushes) != 0 {
+		t.Fatalf("flushed %d times before the delay elapsed", len(flushes))
+	}
+
+	clock.Advance(time.Second)
+	waitFor(t, "the first flush", func() bool {
+		_, flushes := rec.written()
+		return len(flushes) > 0
+	})
+	_, flushes := rec.written()
+	snapshot, update := strings.Index(flushes[0], "event: snapshot"), strings.Index(flushes[0], "event: update")
+	if snapshot < 0 || update < snapshot {
+		t.Errorf("first flush %q, want the snapshot followed by the update", flushes[0])
+	}
+}
+
+func TestSnapshotSortDescending(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	if err := vm.AddCandidate("Candidate C", CandidateInfo{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Candidate C", "Candidate C", "Candidate A"} {
+		if rec := postVote(h, name); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "votes", func() bool { return votesFor(vm, "Candidate C") == 2 && votesFor(vm, "Candidate A") == 1 })
+
+	snapshot := openStream(t, newServer(t, h).URL+"/events?sort=desc").nextOf(t, eventSnapshot).results(t)
+	want := []string{"Candidate C", "Candidate A", "Candidate B"}
+	if got := names(snapshot.Candidates); !slices.Equal(got, want) {
+		t.Errorf("snapshot order %v, want %v", got, want)
+	}
+
+	if rec := serve(h, http.MethodGet, "/events?sort=sideways", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown sort: status %d, want 400", rec.Code)
+	}
+}
+
+func TestUnencodableUpdateFallsBackToMinimalPayload(t *testing.T) {
+	vm := startManager(t, testConfig())
+	stream := openStream(t, newServer(t, vm.Handler()).URL+"/events")
+	stream.nextOf(t, eventSnapshot)
+
+	// A NaN share cannot be marshaled, standing in for any field that fails to encode
+	broken := newResultsPayload([]*Candidate{
+		{Name: "Candidate A", Votes: 3, Percentage: math.NaN()},
+		{Name: "Candidate B", Votes: 1},
+	})
+	vm.clientRequest(cliRequest{action: "snapshot", event: sseEvent{ID: "1", Event: eventUpdate}, snapshot: broken})
+
+	ev := stream.nextOf(t, eventUpdate)
+	if strings.Contains(ev.Data, "percentage") {
+		t.Errorf("fallback payload %s still carries the unencodable field", ev.Data)
+	}
+	got := ev.results(t)
+	if got.Total != 4 || len(got.Candidates) != 2 || got.Candidates[0].Name != "Candidate A" || got.Candidates[0].Votes != 3 {
+		t.Errorf("fallback payload %s, want names, votes and the total of 4", ev.Data)
+	}
+}
+
+func TestConfiguredFieldOrderInRawStream(t *testing.T) {
+	cfg := testConfig()
+	cfg.SSEFieldOrder = []string{sseFieldData, sseFieldEvent, sseFieldID}
+	vm := startManager(t, cfg)
+	stream := openStream(t, newServer(t, vm.Handler()).URL+"/events")
+
+	fields := func(ev streamEvent) []string {
+		var out []string
+		for _, line := range ev.lines {
+			field, _, _ := strings.Cut(line, ":")
+			if len(out) == 0 || out[len(out)-1] != field {
+				out = append(out, field)
+			}
+		}
+		return out
+	}
+	want := []string{"data", "event", "id"}
+	if got := fields(stream.nextOf(t, eventSnapshot)); !slices.Equal(got, want) {
+		t.Errorf("snapshot fields %v, want %v", got, want)
+	}
+	if code := postVote(vm.Handler(), "Candidate A").Code; code != http.StatusAccepted {
+		t.Fatalf("vote: status %d", code)
+	}
+	if got := fields(stream.nextOf(t, eventUpdate)); !slices.Equal(got, want) {
+		t.Errorf("update fields %v, want %v", got, want)
+	}
+}
+
+// logBuffer collects JSON log lines from concurrent goroutines
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (l *logBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.buf.Write(p)
+}
+
+// records decodes every log line with the given message
+func (l *logBuffer) records(t *testing.T, msg string) []map[string]any {
+	t.Helper()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []map[string]any
+	for _, line := range bytes.Split(l.buf.Bytes(), []byte("\n")) {
+		var rec map[string]any
+		if len(line) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(line, &rec); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		if rec["msg"] == msg {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
+
+// captureLogs sends the default logger's output to the returned buffer until the test ends
+func captureLogs(t *testing.T) *logBuffer {
+	logs := &logBuffer{}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return logs
+}
+
+func TestStreamLifecycleLogsShareConnID(t *testing.T) {
+	logs := captureLogs(t)
+	vm := startManager(t, testConfig())
+
+	rec, disconnect := serveStream(t, vm.Handler(), "/events")
+	waitFor(t, "the snapshot", func() bool {
+		_, flushes := rec.written()
+		return len(flushes) > 0
+	})
+	disconnect()
+
+	connected, disconnected := logs.records(t, "SSE client connected"), logs.records(t, "SSE client disconnected")
+	if len(connected) != 1 || len(disconnected) != 1 {
+		t.Fatalf("%d connect and %d disconnect logs, want one each", len(connected), len(disconnected))
+	}
+	id, ok := connected[0]["conn"].(string)
+	if !ok || id == "" {
+		t.Fatalf("connect log %v has no conn ID", connected[0])
+	}
+	if got := disconnected[0]["conn"]; got != id {
+		t.Errorf("disconnect log conn %v, connect log conn %q", got, id)
+	}
+	if disconnected[0]["session"] != connected[0]["session"] {
+		t.Errorf("session %v on disconnect, %v on connect", disconnected[0]["session"], connected[0]["session"])
+	}
+}
+
+func TestEventsDisableProxyBuffering(t *testing.T) {
+	vm := startManager(t, testConfig())
+	stream := openStream(t, newServer(t, vm.Handler()).URL+"/events")
+	if got := stream.resp.Header.Get("X-Accel-Buffering"); got != "no" {
+		t.Errorf("X-Accel-Buffering = %q, want no", got)
+	}
+}
+
+func TestMaxSSEClientsRejectsExtraConnections(t *testing.T) {
+	const limit, attempts = 3, 12
+	cfg := testConfig()
+	cfg.MaxSSEClients = limit
+	ts := newServer(t, startManager(t, cfg).Handler())
+
+	// Connect all at once so the limit is checked under contention
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		accepted int
+		rejected []*http.Response
+	)
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := http.Get(ts.URL + "/events")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if resp.StatusCode == http.StatusOK {
+				accepted++
+				t.Cleanup(func() { resp.Body.Close() })
+				return
+			}
+			resp.Body.Close()
+			rejected = append(rejected, resp)
+		}()
+	}
+	wg.Wait()
+
+	if accepted != limit {
+		t.Errorf("%d of %d connections accepted, want %d", accepted, attempts, limit)
+	}
+	for _, resp := range rejected {
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("rejected connection: status %d, want 503", resp.StatusCode)
+		}
+		if got := resp.Header.Get("Retry-After"); got != "5" {
+			t.Errorf("Retry-After %q, want 5", got)
+		}
+	}
+}
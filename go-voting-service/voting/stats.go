@@ -0,0 +1,68 @@
+package voting
+
+import (
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// memStatsInterval bounds how often runtime.ReadMemStats, which stops the world, is called
+const memStatsInterval = time.Second
+
+// Stats is the payload returned by /stats
+type Stats struct {
+	Clients    int `json:"clients"`
+	TotalVotes int `json:"totalVotes"`
+	Candidates int `json:"candidates"`
+
+	Goroutines     int    `json:"goroutines"`
+	HeapAllocBytes uint64 `json:"heapAllocBytes"`
+	GCPauseTotalNs uint64 `json:"gcPauseTotalNs"`
+	NumGC          uint32 `json:"numGc"`
+
+	CandidatesCreated  uint64 `json:"candidatesCreated"`
+	CreationsThrottled uint64 `json:"candidateCreationsThrottled"`
+	VotesThrottled     uint64 `json:"votesThrottled"`
+}
+
+// memSampler caches runtime.MemStats so frequent /stats scrapes stay cheap
+type memSampler struct {
+	mu      sync.Mutex
+	sampled time.Time
+	stats   runtime.MemStats
+}
+
+// read returns the cached MemStats, refreshing them when older than memStatsInterval
+func (s *memSampler) read(now time.Time) runtime.MemStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.sampled) >= memStatsInterval {
+		runtime.ReadMemStats(&s.stats)
+		s.sampled = now
+	}
+	return s.stats
+}
+
+// statsHandler returns poll and Go runtime metrics for operators. Like /results, the total
+// adds up counts capped at RESULTS_DISPLAY_CAP.
+func (vm *VoteManager) statsHandler(w http.ResponseWriter, r *http.Request) {
+	mem := vm.mem.read(vm.clock.Now())
+	candidates := vm.candidateList()
+	applyDisplayCap(candidates, vm.cfg.ResultsDisplayCap)
+	stats := Stats{
+		Clients:    vm.ClientCount(),
+		TotalVotes: newResultsPayload(candidates).Total,
+		Candidates: len(candidates),
+
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: mem.HeapAlloc,
+		GCPauseTotalNs: mem.PauseTotalNs,
+		NumGC:          mem.NumGC,
+
+		CandidatesCreated:  vm.candidatesCreated.Load(),
+		CreationsThrottled: vm.creationsThrottled.Load(),
+		VotesThrottled:     vm.votesThrottled.Load(),
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,61 @@
+package voting
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestStatsReportsRuntimeMetrics(t *testing.T) {
+	rec := serve(startManager(t, testConfig()).Handler(), http.MethodGet, "/stats", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+	}
+	var fields map[string]json.Number
+	decodeJSON(t, rec, &fields)
+	goroutines, ok := fields["goroutines"]
+	if !ok {
+		t.Fatalf("no goroutines field in %s", rec.Body)
+	}
+	if n, err := goroutines.Int64(); err != nil || n <= 0 {
+		t.Errorf("goroutines = %s, want a positive count", goroutines)
+	}
+	if _, ok := fields["heapAllocBytes"]; !ok {
+		t.Errorf("no heapAllocBytes field in %s", rec.Body)
+	}
+}
+
+func TestStatsClientCountTracksConnections(t *testing.T) {
+	cfg := testConfig()
+	cfg.SlowClientMisses = 1
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+	clients := func() int {
+		var stats Stats
+		decodeJSON(t, serve(h, http.MethodGet, "/stats", ""), &stats)
+		return stats.Clients
+	}
+
+	srv := newServer(t, h)
+	first := openStream(t, srv.URL+"/events")
+	first.nextOf(t, eventSnapshot)
+	openStream(t, srv.URL+"/events").nextOf(t, eventSnapshot)
+	if got := clients(); got != 2 {
+		t.Fatalf("clients = %d with two streams open, want 2", got)
+	}
+
+	// A client that never reads is dropped on its first missed event
+	if err := vm.AddClient(make(chan sseEvent), clientOptions{}, &Session{ID: newID()}); err != nil {
+		t.Fatal(err)
+	}
+	if got := clients(); got != 3 {
+		t.Fatalf("clients = %d after adding a stalled one, want 3", got)
+	}
+	if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+		t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+	}
+	waitFor(t, "the stalled client to be dropped", func() bool { return clients() == 2 })
+
+	first.resp.Body.Close()
+	waitFor(t, "the closed stream to be removed", func() bool { return clients() == 1 })
+}
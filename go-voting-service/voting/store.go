@@ -0,0 +1,131 @@
+package voting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// savedState is the on-disk form of the vote counts
+type savedState struct {
+	SavedAt    time.Time        `json:"savedAt"`
+	Candidates []savedCandidate `json:"candidates"`
+}
+
+// savedCandidate is one candidate's persisted count and metadata
+type savedCandidate struct {
+	Name  string `json:"name"`
+	Votes int    `json:"votes"`
+	CandidateInfo
+}
+
+// Save writes the current counts to path as JSON. It writes a temp file in the same directory
+// and renames it over path, so a crash mid-write never leaves a truncated file behind.
+func (vm *VoteManager) Save(path string) error {
+	vm.mu.RLock()
+	state := savedState{SavedAt: vm.clock.Now(), Candidates: make([]savedCandidate, 0, len(vm.candidates))}
+	for _, candidate := range vm.candidates {
+		state.Candidates = append(state.Candidates, savedCandidate{Name: candidate.Name, Votes: candidate.Votes, CandidateInfo: candidate.CandidateInfo})
+	}
+	vm.mu.RUnlock()
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load replaces the candidates with the counts saved at path. Call it before Start.
+// The current candidates are left untouched if the file is missing or invalid.
+func (vm *VoteManager) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var state savedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	candidates := make(map[string]*Candidate, len(state.Candidates))
+	for _, c := range state.Candidates {
+		if err := validateCandidateName(c.Name); err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		if c.Votes < 0 {
+			return fmt.Errorf("parse %s: negative votes for %q", path, c.Name)
+		}
+		candidates[c.Name] = &Candidate{Name: c.Name, Votes: c.Votes, CandidateInfo: c.CandidateInfo}
+	}
+
+	vm.mu.Lock()
+	vm.candidates = candidates
+	vm.mu.Unlock()
+	return nil
+}
+
+// loadSaved restores DataFile at startup. A missing file means a first run; a corrupt one is
+// logged and moved aside to DataFile.corrupt, and the poll starts fresh rather than failing to boot.
+func (vm *VoteManager) loadSaved() {
+	err := vm.Load(vm.cfg.DataFile)
+	switch {
+	case err == nil:
+		slog.Info("Loaded vote counts", "path", vm.cfg.DataFile)
+	case errors.Is(err, fs.ErrNotExist):
+	default:
+		slog.Error("Failed to load vote counts, starting fresh", "path", vm.cfg.DataFile, "error", err)
+		if err := os.Rename(vm.cfg.DataFile, vm.cfg.DataFile+".corrupt"); err != nil {
+			slog.Error("Failed to move aside corrupt vote counts", "error", err)
+		}
+	}
+}
+
+// runSaver saves DataFile every SaveInterval while votes have been counted since the last save,
+// so bursts of votes cost one write. Stop does the final save.
+func (vm *VoteManager) runSaver() {
+	for {
+		select {
+		case <-vm.clock.After(vm.cfg.SaveInterval):
+		case <-vm.done:
+			return
+		}
+		if !vm.dirty.Swap(false) {
+			continue
+		}
+		if err := vm.Save(vm.cfg.DataFile); err != nil {
+			vm.dirty.Store(true)
+			slog.Error("Failed to save vote counts", "error", err)
+		}
+	}
+}
+
+// checkDataDir verifies that the directory holding path accepts new files
+func checkDataDir(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".write-check-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
@@ -0,0 +1,59 @@
+package voting
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVotesSurviveRestart(t *testing.T) {
+	dir := t.TempDir()
+	cfg := testConfig()
+	cfg.DataFile = filepath.Join(dir, "votes.json")
+
+	first := startManager(t, cfg)
+	for _, name := range []string{"Candidate A", "Candidate A", "Candidate B"} {
+		if rec := postVote(first.Handler(), name); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	first.Stop()
+
+	// The save renamed its temp file into place
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "votes.json" {
+		t.Errorf("data dir holds %v, want only votes.json", entries)
+	}
+
+	second := startManager(t, cfg)
+	if a, b := votesFor(second, "Candidate A"), votesFor(second, "Candidate B"); a != 2 || b != 1 {
+		t.Errorf("after restart A has %d and B %d votes, want 2 and 1", a, b)
+	}
+}
+
+func TestCorruptDataFileStartsFresh(t *testing.T) {
+	for name, contents := range map[string]string{
+		"truncated": `{"candidates":[{"name":"Candidate A","vot`,
+		"invalid":   `{"candidates":[{"name":"","votes":3}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.DataFile = filepath.Join(t.TempDir(), "votes.json")
+			if err := os.WriteFile(cfg.DataFile, []byte(contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			vm := startManager(t, cfg)
+			if a, b := votesFor(vm, "Candidate A"), votesFor(vm, "Candidate B"); a != 0 || b != 0 {
+				t.Errorf("started with A at %d and B at %d, want a fresh poll", a, b)
+			}
+			if got, err := os.ReadFile(cfg.DataFile + ".corrupt"); err != nil || string(got) != contents {
+				t.Errorf("corrupt file not moved aside intact: %q, %v", got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package voting
+
+import "time"
+
+// candidateThrottle coalesces rapid updates per candidate. It is owned by the vote-processing goroutine.
+type candidateThrottle struct {
+	lastSent map[string]time.Time
+	pending  map[string]bool // a deferred send is already scheduled
+
+	updatePending bool // counts changed since the last coalesced broadcast
+}
+
+// flushUpdate broadcasts one update for every change coalesced since the last flush
+func (vm *VoteManager) flushUpdate() {
+	if vm.throttle.updatePending {
+		vm.throttle.updatePending = false
+		vm.notifyClients(eventUpdate)
+	}
+}
+
+// notifyCandidate broadcasts the results after candidate changed, at most once per candidate every
+// CandidateThrottle. Updates inside the window collapse into one deferred snapshot with the latest counts.
+func (vm *VoteManager) notifyCandidate(candidate *Candidate) {
+	// Coalescing batches every update until the next flush tick
+	if vm.cfg.BroadcastInterval > 0 {
+		vm.throttle.updatePending = true
+		return
+	}
+	interval := vm.cfg.CandidateThrottle
+	if interval <= 0 {
+		vm.notifyClients(eventUpdate)
+		return
+	}
+
+	name := candidate.Name
+	now := vm.clock.Now()
+	last, sent := vm.throttle.lastSent[name]
+	if !sent || now.Sub(last) >= interval {
+		vm.throttle.lastSent[name] = now
+		vm.notifyClients(eventUpdate)
+		return
+	}
+	if vm.throttle.pending[name] {
+		return
+	}
+	vm.throttle.pending[name] = true
+	vm.clock.AfterFunc(last.Add(interval).Sub(now), func() {
+		vm.do(func() {
+			delete(vm.throttle.pending, name)
+			vm.throttle.lastSent[name] = vm.clock.Now()
+			vm.notifyClients(eventUpdate)
+		})
+	})
+}
@@ -0,0 +1,47 @@
+package voting
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCandidateThrottleLeavesOtherCandidatesImmediate(t *testing.T) {
+	clock := newFakeClock()
+	cfg := testConfig()
+	cfg.CandidateThrottle = time.Second
+	vm := startManagerWithClock(t, cfg, clock)
+	h := vm.Handler()
+	stream := openStream(t, newServer(t, h).URL+"/events")
+	stream.nextOf(t, eventSnapshot)
+
+	const flood = 10
+	for range flood {
+		if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "the flood", func() bool { return votesFor(vm, "Candidate A") == flood })
+	if rec := postVote(h, "Candidate B"); rec.Code != http.StatusAccepted {
+		t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+	}
+
+	// Only the flood's first vote goes out before B's, which is not held back by A's throttle
+	floodUpdates := 0
+	for {
+		update := stream.nextOf(t, eventUpdate).results(t)
+		if update.votes("Candidate B") == 1 {
+			break
+		}
+		floodUpdates++
+	}
+	if floodUpdates != 1 {
+		t.Errorf("%d updates for the flood before the throttle window ended, want 1", floodUpdates)
+	}
+
+	// The rest of the flood arrives as one deferred update once the window ends
+	clock.Advance(time.Second)
+	if got := stream.nextOf(t, eventUpdate).results(t).votes("Candidate A"); got != flood {
+		t.Errorf("deferred update has Candidate A at %d, want %d", got, flood)
+	}
+}
@@ -0,0 +1,66 @@
+package voting
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// eventSession is the SSE event type carrying a fresh session token
+const eventSession = "session"
+
+// sessionTokens maps SSE session tokens to session IDs. The current token of a live session
+// never expires; replaced or released tokens stay valid only until their grace period ends.
+type sessionTokens struct {
+	mu     sync.Mutex
+	tokens map[string]tokenEntry
+}
+
+// tokenEntry records the session a token belongs to; a zero expires means it is current
+type tokenEntry struct {
+	sessionID string
+	expires   time.Time
+}
+
+// issue creates a new current token for sessionID
+func (st *sessionTokens) issue(sessionID string) string {
+	raw := make([]byte, 16)
+	rand.Read(raw)
+	token := hex.EncodeToString(raw)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.tokens[token] = tokenEntry{sessionID: sessionID}
+	return token
+}
+
+// expire lets token remain valid until at, then forgets it
+func (st *sessionTokens) expire(token string, at time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if entry, ok := st.tokens[token]; ok {
+		entry.expires = at
+		st.tokens[token] = entry
+	}
+}
+
+// lookup returns the session ID for a valid token, pruning expired tokens as it goes
+func (st *sessionTokens) lookup(token string, now time.Time) (string, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for t, entry := range st.tokens {
+		if !entry.expires.IsZero() && !now.Before(entry.expires) {
+			delete(st.tokens, t)
+		}
+	}
+	entry, ok := st.tokens[token]
+	return entry.sessionID, ok
+}
+
+// sessionEvent builds the SSE event announcing token to the client
+func sessionEvent(token string) sseEvent {
+	data, _ := json.Marshal(map[string]string{"token": token})
+	return sseEvent{Event: eventSession, Data: string(data)}
+}
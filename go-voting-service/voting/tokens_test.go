@@ -0,0 +1,43 @@
+package voting
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSessionTokenRotation(t *testing.T) {
+	clock := newFakeClock()
+	cfg := testConfig()
+	cfg.SSETokenRotation = time.Minute
+	cfg.SSETokenGrace = 10 * time.Second
+	vm := startManagerWithClock(t, cfg, clock)
+	h := vm.Handler()
+	stream := openStream(t, newServer(t, h).URL+"/events")
+
+	token := func() string {
+		var body struct{ Token string }
+		ev := stream.nextOf(t, eventSession)
+		if err := json.Unmarshal([]byte(ev.Data), &body); err != nil || body.Token == "" {
+			t.Fatalf("invalid session event %q", ev.Data)
+		}
+		return body.Token
+	}
+	first := token()
+
+	clock.Advance(time.Minute)
+	second := token()
+	if second == first {
+		t.Fatal("rotation reissued the same token")
+	}
+
+	// The replaced token outlives the grace period only until it ends
+	clock.Advance(cfg.SSETokenGrace + time.Second)
+	if rec := serve(h, http.MethodGet, "/events?session="+first, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("replaced token after the grace period: status %d, want 401", rec.Code)
+	}
+	// The current token still resumes the session
+	resumed := openStream(t, newServer(t, h).URL+"/events?session="+second)
+	resumed.nextOf(t, eventSnapshot)
+}
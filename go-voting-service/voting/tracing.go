@@ -0,0 +1,82 @@
+package voting
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serviceName identifies this service in exported traces
+const serviceName = "go-voting-service"
+
+// tracer starts every span in the package. It goes through the global provider, so spans are
+// no-ops until SetupTracing installs an exporting one.
+var tracer = otel.Tracer("go-voting-service/voting")
+
+// Span attributes recorded on vote handling
+const (
+	attrCandidate = attribute.Key("vote.candidate")
+	attrDropped   = attribute.Key("vote.dropped")
+	attrEvent     = attribute.Key("sse.event")
+	attrClients   = attribute.Key("sse.clients")
+)
+
+// SetupTracing exports spans to cfg.OTLPEndpoint and extracts W3C trace context from incoming
+// requests. Without an endpoint it leaves tracing off. The returned func flushes any pending
+// spans and must be called on shutdown.
+func SetupTracing(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(buildInfo().Version),
+	))
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// startRequestSpan starts a server span for r, continuing the caller's trace if its headers
+// carry one
+func startRequestSpan(r *http.Request, name string) trace.Span {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	_, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer))
+	return span
+}
+
+// startSpan starts a span of internal work that is not tied to a request
+func startSpan(name string, attrs ...attribute.KeyValue) trace.Span {
+	_, span := tracer.Start(context.Background(), name, trace.WithAttributes(attrs...))
+	return span
+}
+
+// startVoteSpan starts the span for processing v as a child of the request that admitted it.
+// Votes are counted after the handler has answered, so the parent may already have ended.
+func startVoteSpan(v vote) trace.Span {
+	ctx := trace.ContextWithSpanContext(context.Background(), v.span)
+	_, span := tracer.Start(ctx, "processVote", trace.WithAttributes(attrCandidate.String(v.candidate)))
+	return span
+}
+
+// failSpan marks span as failed for reason
+func failSpan(span trace.Span, reason string) {
+	span.SetStatus(codes.Error, reason)
+}
@@ -0,0 +1,47 @@
+package voting
+
+import (
+	"math"
+	"net/http"
+)
+
+// Turnout is the payload returned by /results/turnout
+type Turnout struct {
+	TotalVotes     int     `json:"totalVotes"`
+	ExpectedVoters int     `json:"expectedVoters"`
+	TurnoutPercent float64 `json:"turnoutPercent"`
+	Quorum         float64 `json:"quorum"`
+	QuorumMet      bool    `json:"quorumMet"`
+}
+
+// turnout compares total votes to the expected electorate. Quorum is met once the votes reach
+// QuorumThreshold of ExpectedVoters, rounded up to a whole vote. The reported total and
+// percentage add up counts capped at RESULTS_DISPLAY_CAP; only QuorumMet uses the exact total.
+func (vm *VoteManager) turnout() Turnout {
+	vm.mu.RLock()
+	total, shown := 0, 0
+	for _, candidate := range vm.candidates {
+		votes, _ := capped(candidate.Votes, vm.cfg.ResultsDisplayCap)
+		total += candidate.Votes
+		shown += votes
+	}
+	vm.mu.RUnlock()
+
+	expected := vm.cfg.ExpectedVoters
+	return Turnout{
+		TotalVotes:     shown,
+		ExpectedVoters: expected,
+		TurnoutPercent: math.Round(float64(shown)/float64(expected)*10000) / 100,
+		Quorum:         vm.cfg.QuorumThreshold,
+		QuorumMet:      float64(total) >= math.Ceil(vm.cfg.QuorumThreshold*float64(expected)),
+	}
+}
+
+// turnoutHandler returns turnout and quorum status, or 404 without EXPECTED_VOTERS
+func (vm *VoteManager) turnoutHandler(w http.ResponseWriter, r *http.Request) {
+	if vm.cfg.ExpectedVoters == 0 {
+		writeJSONError(w, http.StatusNotFound, "Turnout is not configured")
+		return
+	}
+	writeJSON(w, http.StatusOK, vm.turnout())
+}
@@ -0,0 +1,40 @@
+package voting
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestTurnoutQuorumFlipsAtThreshold(t *testing.T) {
+	cfg := testConfig()
+	cfg.ExpectedVoters = 10
+	cfg.QuorumThreshold = 0.25 // 2.5 votes, so quorum needs 3
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+
+	turnout := func() Turnout {
+		var out Turnout
+		decodeJSON(t, serve(h, http.MethodGet, "/results/turnout", ""), &out)
+		return out
+	}
+	for votes := 1; votes <= 3; votes++ {
+		if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+		waitFor(t, "the vote", func() bool { return votesFor(vm, "Candidate A") == votes })
+		got := turnout()
+		if got.TotalVotes != votes || got.TurnoutPercent != float64(votes*10) {
+			t.Errorf("after %d votes: total %d, turnout %v%%", votes, got.TotalVotes, got.TurnoutPercent)
+		}
+		if want := votes >= 3; got.QuorumMet != want {
+			t.Errorf("after %d votes: quorumMet %v, want %v", votes, got.QuorumMet, want)
+		}
+	}
+}
+
+func TestTurnoutNotConfigured(t *testing.T) {
+	rec := serve(startManager(t, testConfig()).Handler(), http.MethodGet, "/results/turnout", "")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status %d, want 404 without EXPECTED_VOTERS", rec.Code)
+	}
+}
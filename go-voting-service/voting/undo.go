@@ -0,0 +1,64 @@
+package voting
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+var errNoVotes = errors.New("candidate has no votes to remove")
+
+// Unvote takes one vote back from the named candidate on the vote-processing goroutine, so it
+// never races a concurrent vote, and broadcasts the updated results. Counts never go below
+// zero: a candidate without votes is left alone and errNoVotes is returned.
+func (vm *VoteManager) Unvote(name string) (VoteResult, error) {
+	var result VoteResult
+	var err error
+	ok := vm.do(func() {
+		now := vm.clock.Now()
+		vm.mu.Lock()
+		candidate, exists := vm.candidates[name]
+		switch {
+		case !exists:
+			err = errCandidateNotFound
+		case candidate.Votes == 0:
+			err = errNoVotes
+		default:
+			vm.countVote(candidate, now, -1)
+			result = VoteResult{Candidate: name, Votes: candidate.Votes}
+		}
+		vm.mu.Unlock()
+		if err == nil {
+			vm.auditRecord(AuditRecord{Time: now, Action: auditActionUnvote, Candidate: name, Weight: 1})
+			vm.notifyClients(eventUpdate)
+		}
+	})
+	if !ok {
+		return result, errStopped
+	}
+	if err == nil {
+		slog.Info("Vote removed", "candidate", name, "votes", result.Votes)
+	}
+	return result, err
+}
+
+// unvoteHandler removes one vote from the candidate named by ?candidate and returns its new
+// count. It answers 404 for unknown candidates and 409 when the count is already zero.
+func (vm *VoteManager) unvoteHandler(w http.ResponseWriter, r *http.Request) {
+	if vm.cfg.ReadOnly {
+		writeJSONError(w, http.StatusServiceUnavailable, "Voting is unavailable: server is read-only")
+		return
+	}
+	result, err := vm.Unvote(strings.TrimSpace(r.URL.Query().Get("candidate")))
+	switch err {
+	case nil:
+		writeJSON(w, http.StatusOK, result)
+	case errCandidateNotFound:
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	case errNoVotes:
+		writeJSONError(w, http.StatusConflict, err.Error())
+	default:
+		writeJSONError(w, http.StatusServiceUnavailable, err.Error())
+	}
+}
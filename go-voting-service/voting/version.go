@@ -0,0 +1,60 @@
+package voting
+
+import (
+	"cmp"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// devBuild is reported for any build detail that is unknown
+const devBuild = "dev"
+
+// Build details, normally set at link time:
+//
+//	go build -ldflags "-X go-voting-service/voting.Version=v1.2.0 -X go-voting-service/voting.Commit=$(git rev-parse HEAD) -X go-voting-service/voting.BuildTime=$(date -u +%FT%TZ)"
+//
+// Any left empty falls back to the module and VCS information Go embeds in the binary.
+var (
+	Version   string
+	Commit    string
+	BuildTime string
+)
+
+// BuildInfo is the payload returned by /version
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// buildInfo resolves the build details once; they cannot change while the process runs
+var buildInfo = sync.OnceValue(func() BuildInfo {
+	var version, commit, buildTime string
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Version != "(devel)" {
+			version = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				commit = setting.Value
+			case "vcs.time":
+				buildTime = setting.Value
+			}
+		}
+	}
+	return BuildInfo{
+		Version:   cmp.Or(Version, version, devBuild),
+		Commit:    cmp.Or(Commit, commit, devBuild),
+		BuildTime: cmp.Or(BuildTime, buildTime, devBuild),
+		GoVersion: runtime.Version(),
+	}
+})
+
+// versionHandler reports which build is running
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, buildInfo())
+}
@@ -0,0 +1,105 @@
+package voting
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// maxVoteBodyBytes bounds a form-encoded or JSON vote body
+const maxVoteBodyBytes = 4 << 10
+
+var (
+	errVoteBodyTooLarge = errors.New("vote body is too large")
+	errInvalidVoteBody  = errors.New("invalid vote body")
+	errInvalidWeight    = errors.New("weight must be a positive integer")
+)
+
+// VoteRequest is a vote as sent in a JSON body
+type VoteRequest struct {
+	Candidate string `json:"candidate"`
+	Voter     string `json:"voter"`
+
+	// Weight is how many votes the ballot counts for; empty means 1
+	Weight json.Number `json:"weight"`
+}
+
+// weight returns the requested ballot weight, rejecting non-numeric and non-positive
+// values and anything above max
+func (req VoteRequest) weight(max int) (int, error) {
+	if req.Weight == "" {
+		return 1, nil
+	}
+	weight, err := strconv.Atoi(req.Weight.String())
+	if err != nil || weight < 1 {
+		return 0, errInvalidWeight
+	}
+	if weight > max {
+		return 0, fmt.Errorf("weight must not exceed %d", max)
+	}
+	return weight, nil
+}
+
+// VoteResult is the body of a synchronous vote response
+type VoteResult struct {
+	Candidate string `json:"candidate"`
+	Votes     int    `json:"votes"`
+}
+
+// awaitVote answers 200 with the candidate's new count once the processing goroutine has
+// counted the vote. Without a reply channel, or if the vote is not counted within
+// SyncVoteTimeout, it falls back to 202 so a stuck processor never hangs the request.
+func (vm *VoteManager) awaitVote(w http.ResponseWriter, r *http.Request, reply chan VoteResult) {
+	if reply != nil {
+		select {
+		case result, ok := <-reply:
+			if ok {
+				writeJSON(w, http.StatusOK, result)
+				return
+			}
+		case <-vm.clock.After(vm.cfg.SyncVoteTimeout):
+		case <-r.Context().Done():
+		}
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// parseVoteRequest reads the candidate, voter and weight from a JSON body, a form-encoded body or the
+// query string, so plain HTML forms work as well as scripts. Body fields win over the query.
+// It returns the HTTP status to answer with when the body cannot be read.
+func parseVoteRequest(w http.ResponseWriter, r *http.Request) (VoteRequest, int, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxVoteBodyBytes)
+	var req VoteRequest
+	var err error
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		// An empty body falls back to the query string like a plain POST
+		if err = json.NewDecoder(r.Body).Decode(&req); err == io.EOF {
+			err = nil
+		}
+		query := r.URL.Query()
+		if req.Candidate == "" {
+			req.Candidate = query.Get("candidate")
+		}
+		if req.Voter == "" {
+			req.Voter = query.Get("voter")
+		}
+		if req.Weight == "" {
+			req.Weight = json.Number(query.Get("weight"))
+		}
+	} else if err = r.ParseForm(); err == nil {
+		req.Candidate = r.Form.Get("candidate")
+		req.Voter = r.Form.Get("voter")
+		req.Weight = json.Number(r.Form.Get("weight"))
+	}
+	if err != nil {
+		if maxErr := new(http.MaxBytesError); errors.As(err, &maxErr) {
+			return req, http.StatusRequestEntityTooLarge, errVoteBodyTooLarge
+		}
+		return req, http.StatusBadRequest, errInvalidVoteBody
+	}
+	return req, http.StatusOK, nil
+}
@@ -0,0 +1,42 @@
+package voting
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestFormEncodedVote(t *testing.T) {
+	vm := startManager(t, testConfig())
+	rec := serve(vm.Handler(), http.MethodPost, "/vote", "candidate=Candidate+B", "Content-Type", "application/x-www-form-urlencoded")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status %d, body %s", rec.Code, rec.Body)
+	}
+	waitFor(t, "the form vote", func() bool { return votesFor(vm, "Candidate B") == 1 })
+}
+
+func TestChangedVoteRetractsRequestedWeight(t *testing.T) {
+	cfg := testConfig()
+	cfg.Poll.VoterMode = voterModeChange
+	cfg.MaxVoteWeight = 5
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+
+	for _, body := range []string{
+		`{"candidate":"Candidate A","voter":"bob"}`,
+		`{"candidate":"Candidate A","voter":"alice","weight":3}`,
+	} {
+		if rec := serve(h, http.MethodPost, "/vote", body); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote %s: status %d, body %s", body, rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "the first votes", func() bool { return votesFor(vm, "Candidate A") == 4 })
+
+	// The change asks for weight 1, but the ballot it replaces counted 3
+	if rec := serve(h, http.MethodPost, "/vote", `{"candidate":"Candidate B","voter":"alice","weight":1}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("changed vote: status %d, body %s", rec.Code, rec.Body)
+	}
+	waitFor(t, "the changed vote", func() bool { return votesFor(vm, "Candidate B") == 1 })
+	if got := votesFor(vm, "Candidate A"); got != 1 {
+		t.Errorf("Candidate A has %d votes after the change, want bob's 1", got)
+	}
+}
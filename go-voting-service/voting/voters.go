@@ -0,0 +1,185 @@
+package voting
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	errAlreadyPicked = errors.New("voter has already picked this candidate")
+	errPickLimit     = errors.New("voter has reached the maximum number of picks")
+)
+
+// voterPicks tracks which distinct candidates each voter has picked
+type voterPicks struct {
+	mu    sync.Mutex
+	max   int // 0 disables tracking
+	picks map[string]map[string]struct{}
+}
+
+// newVoterPicks returns a tracker allowing up to maxPicks distinct picks per voter
+func newVoterPicks(maxPicks int) *voterPicks {
+	return &voterPicks{max: maxPicks, picks: make(map[string]map[string]struct{})}
+}
+
+// record registers candidate as one of voter's picks, enforcing the per-voter cap
+func (vp *voterPicks) record(voter, candidate string) error {
+	if vp.max == 0 || voter == "" {
+		return nil
+	}
+	vp.mu.Lock()
+	defer vp.mu.Unlock()
+
+	picked := vp.picks[voter]
+	if _, ok := picked[candidate]; ok {
+		return errAlreadyPicked
+	}
+	if len(picked) >= vp.max {
+		return errPickLimit
+	}
+	if picked == nil {
+		picked = make(map[string]struct{})
+		vp.picks[voter] = picked
+	}
+	picked[candidate] = struct{}{}
+	return nil
+}
+
+// reset forgets every voter's picks
+func (vp *voterPicks) reset() {
+	vp.mu.Lock()
+	defer vp.mu.Unlock()
+	clear(vp.picks)
+}
+
+// release undoes a pick whose vote could not be accepted
+func (vp *voterPicks) release(voter, candidate string) {
+	if vp.max == 0 || voter == "" {
+		return
+	}
+	vp.mu.Lock()
+	defer vp.mu.Unlock()
+
+	delete(vp.picks[voter], candidate)
+	if len(vp.picks[voter]) == 0 {
+		delete(vp.picks, voter)
+	}
+}
+
+// Voter modes selected by VOTER_MODE
+const (
+	voterModeUnlimited = "unlimited" // demo mode: every request counts
+	voterModeOnce      = "once"      // one vote per voter
+	voterModeChange    = "change"    // a repeat vote moves the voter's vote to the new candidate
+)
+
+// voterCookieName holds the server-assigned voter ID for clients that send none
+const voterCookieName = "voter_id"
+
+var errAlreadyVoted = errors.New("voter has already voted")
+
+// AlreadyVoted is the 409 body returned when a voter may not vote again
+type AlreadyVoted struct {
+	Error     string `json:"error"`
+	Status    int    `json:"status"`
+	Candidate string `json:"candidate"`
+}
+
+// voterCookie returns the voter ID from the voter cookie, assigning a new one if it is missing
+func voterCookie(w http.ResponseWriter, r *http.Request) string {
+	id, cookie := readVoterCookie(r)
+	if cookie != nil {
+		http.SetCookie(w, cookie)
+	}
+	return id
+}
+
+// readVoterCookie returns the voter ID from r's voter cookie. When the cookie is missing it
+// assigns a new ID and also returns the cookie the caller must set to keep it.
+func readVoterCookie(r *http.Request) (string, *http.Cookie) {
+	if c, err := r.Cookie(voterCookieName); err == nil && c.Value != "" {
+		return c.Value, nil
+	}
+	id := newID()
+	return id, &http.Cookie{
+		Name:     voterCookieName,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   365 * 24 * 60 * 60,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// claimBallot records candidate as voter's choice on the processing goroutine. In once mode a
+// voter who already voted gets errAlreadyVoted; in change mode the replaced candidate is returned
+// so the vote can move. Voting for the current choice again is errAlreadyVoted in both modes.
+// The earlier candidate is returned alongside errAlreadyVoted.
+func (vm *VoteManager) claimBallot(voter, candidate string) (string, error) {
+	if vm.cfg.Poll.VoterMode == voterModeUnlimited || voter == "" {
+		return "", nil
+	}
+	var previous string
+	var err error
+	ok := vm.do(func() {
+		prev, voted := vm.votedBy[voter]
+		previous = prev
+		if voted && (vm.cfg.Poll.VoterMode == voterModeOnce || prev == candidate) {
+			err = errAlreadyVoted
+			return
+		}
+		vm.votedBy[voter] = candidate
+	})
+	if !ok {
+		return "", errStopped
+	}
+	return previous, err
+}
+
+// releaseBallot restores voter's previous choice after their vote could not be accepted
+func (vm *VoteManager) releaseBallot(voter, candidate, previous string) {
+	if vm.cfg.Poll.VoterMode == voterModeUnlimited || voter == "" {
+		return
+	}
+	vm.do(func() {
+		if vm.votedBy[voter] != candidate {
+			return
+		}
+		if previous == "" {
+			delete(vm.votedBy, voter)
+		} else {
+			vm.votedBy[voter] = previous
+		}
+	})
+}
+
+// ballot is a change-mode voter's counted vote
+type ballot struct {
+	candidate string
+	weight    int // weight the vote was counted with
+}
+
+// retractVote records v, counted with weight, as its voter's ballot in change mode and takes
+// the ballot it replaces back from that candidate with the weight it was counted with. It
+// returns the candidate the vote was taken from and how many votes it lost, or nil if there is
+// none. Callers hold vm.mu on the processing goroutine.
+func (vm *VoteManager) retractVote(v vote, now time.Time, weight int) (*Candidate, int) {
+	if vm.cfg.Poll.VoterMode != voterModeChange || v.voter == "" {
+		return nil, 0
+	}
+	replaced, counted := vm.ballots[v.voter]
+	vm.ballots[v.voter] = ballot{candidate: v.candidate, weight: weight}
+	if !counted {
+		return nil, 0
+	}
+	previous, exists := vm.candidates[replaced.candidate]
+	if !exists {
+		return nil, 0
+	}
+	// An unvote may have taken votes since; never take more than the candidate has
+	taken := min(replaced.weight, previous.Votes)
+	vm.countVote(previous, now, -taken)
+	return previous, taken
+}
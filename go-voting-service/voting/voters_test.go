@@ -0,0 +1,44 @@
+package voting
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPickLimitPerVoter(t *testing.T) {
+	cfg := testConfig()
+	cfg.Poll.MaxPicks = 2
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+	if err := vm.AddCandidate("Candidate C", CandidateInfo{}); err != nil {
+		t.Fatal(err)
+	}
+
+	vote := func(candidate string) int {
+		return serve(h, http.MethodPost, "/vote", `{"candidate":"`+candidate+`","voter":"alice"}`).Code
+	}
+	for _, candidate := range []string{"Candidate A", "Candidate B"} {
+		if code := vote(candidate); code != http.StatusAccepted {
+			t.Fatalf("pick %s: status %d, want 202", candidate, code)
+		}
+	}
+	if code := vote("Candidate C"); code != http.StatusConflict {
+		t.Errorf("third distinct pick: status %d, want 409", code)
+	}
+	if code := vote("Candidate A"); code != http.StatusConflict {
+		t.Errorf("repeat pick: status %d, want 409", code)
+	}
+
+	waitFor(t, "picks", func() bool { return votesFor(vm, "Candidate A") == 1 && votesFor(vm, "Candidate B") == 1 })
+	if got := votesFor(vm, "Candidate C"); got != 0 {
+		t.Errorf("Candidate C has %d votes, want 0", got)
+	}
+	if got := votesFor(vm, "Candidate A"); got != 1 {
+		t.Errorf("Candidate A has %d votes after the repeat, want 1", got)
+	}
+
+	// Another voter has picks of their own
+	if code := serve(h, http.MethodPost, "/vote", `{"candidate":"Candidate C","voter":"bob"}`).Code; code != http.StatusAccepted {
+		t.Errorf("other voter: status %d, want 202", code)
+	}
+}
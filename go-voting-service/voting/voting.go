@@ -0,0 +1,1192 @@
+// Package voting counts votes for a set of candidates and streams the results to clients over
+// Server-Sent Events. NewVoteManager builds the service, Start and Stop run it, and Handler
+// returns the HTTP endpoints so they can be served directly or mounted under a prefix.
+package voting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Candidate structure to hold candidate data
+type Candidate struct {
+	Name  string `json:"name"`
+	Votes int    `json:"votes"`
+
+	// Percentage is the share of all votes, rounded to two decimals
+	Percentage float64 `json:"percentage"`
+
+	// DecayedVotes weights recent votes more, per DECAY_HALF_LIFE; nil when decay is off
+	DecayedVotes *float64 `json:"decayedVotes,omitempty"`
+
+	// Rate is the votes cast in the last minute
+	Rate int `json:"rate"`
+
+	// ShareBps is the share of all votes in basis points (0-10000); nil unless RESULTS_BASIS_POINTS is set
+	ShareBps *int `json:"shareBps,omitempty"`
+
+	// Capped is set when Votes shows RESULTS_DISPLAY_CAP rather than the exact count
+	Capped bool `json:"capped,omitempty"`
+
+	CandidateInfo
+
+	decay     decay
+	rate      voteRate
+	milestone int // last milestone announced
+}
+
+// ResultsPayload is the body of /results and of every results message on the stream, so
+// clients can simply replace their state with it
+type ResultsPayload struct {
+	Candidates []*Candidate `json:"candidates"`
+	Total      int          `json:"total"`
+
+	// Delta lists the candidates changed since the previous broadcast; it is sent only to
+	// ?composite=true streams and only on live events
+	Delta []*Candidate `json:"delta,omitempty"`
+
+	// EventMeta is set on stream events only
+	*EventMeta
+}
+
+// minimalCandidate is the fallback wire shape of a candidate that cannot be encoded
+type minimalCandidate struct {
+	Name  string `json:"name"`
+	Votes int    `json:"votes"`
+}
+
+// minimalResults is the fallback wire shape of a results payload that cannot be encoded
+type minimalResults struct {
+	Candidates []minimalCandidate `json:"candidates"`
+	Total      int                `json:"total"`
+	*EventMeta
+}
+
+// encodeResults encodes p for the stream, falling back to just names, votes and the total so
+// a marshaling failure never silently drops an update
+func encodeResults(p ResultsPayload) string {
+	data, err := json.Marshal(p)
+	if err == nil {
+		return string(data)
+	}
+	slog.Error("Failed to marshal results, sending minimal payload", "error", err)
+	minimal := minimalResults{Candidates: make([]minimalCandidate, len(p.Candidates)), Total: p.Total, EventMeta: p.EventMeta}
+	for i, c := range p.Candidates {
+		minimal.Candidates[i] = minimalCandidate{Name: c.Name, Votes: c.Votes}
+	}
+	data, _ = json.Marshal(minimal)
+	return string(data)
+}
+
+// setMeta stamps a results event; plain /results bodies carry no stamp
+func (s *ResultsPayload) setMeta(meta EventMeta) {
+	s.EventMeta = &meta
+}
+
+// vote is a single accepted vote awaiting processing
+type vote struct {
+	candidate string
+	voter     string // dedup key: the caller's voter ID, or a voter cookie or session ID in once and change modes
+	identity  string // voter ID the caller supplied; empty for anonymous votes
+	previous  string // candidate this vote replaces in change mode
+	epoch     uint64 // reset epoch the vote was admitted in
+	weight    int    // ballot weight requested by the voter, at least 1
+	ip        string // client IP, for the audit log
+
+	// span is the request span that admitted the vote, the parent of its processing span
+	span trace.SpanContext
+
+	// reply, when set, receives the candidate's new count once the vote is counted and is
+	// closed either way; it is buffered so processing never blocks on it
+	reply chan VoteResult
+}
+
+// answer reports the outcome to a waiting voteHandler; a nil result means the vote was not counted
+func (v vote) answer(result *VoteResult) {
+	if v.reply == nil {
+		return
+	}
+	if result != nil {
+		v.reply <- *result
+	}
+	close(v.reply)
+}
+
+// VoteManager manages votes and client notifications
+type VoteManager struct {
+	mu          sync.RWMutex // Guards candidates; only the vote-processing goroutine writes
+	candidates  map[string]*Candidate
+	voteChannel chan vote
+	clients     map[chan sseEvent]*client
+	clientCount atomic.Int64 // len(clients), published by manageClients for lock-free reads
+	cliRequests chan cliRequest
+	stopClients chan struct{} // Closed by disconnectClients to shut manageClients down
+	stopOnce    sync.Once
+	clientsDone chan struct{} // Closed when manageClients has exited
+	sockets     socketTracker // Open /ws connections
+	wg          sync.WaitGroup
+	cfg         Config
+	picks       *voterPicks
+	seq         atomic.Uint64 // Sequence of the latest broadcast
+	ops         chan func()   // Operations run on the vote-processing goroutine
+	done        chan struct{} // Closed when the vote-processing goroutine exits
+	clock       Clock
+	state       pollState
+	mem         memSampler
+	history     voteHistory
+	throttle    candidateThrottle
+	tokens      sessionTokens
+	replay      eventRing      // recent broadcasts for Last-Event-ID reconnects
+	changes     changeNotifier // wakes /results/poll waiters when seq advances
+	metrics     *metrics
+	lastCounts  countsSnapshot    // counts at the previous broadcast, for composite deltas
+	votedBy     map[string]string // voter ID to chosen candidate; owned by the processing goroutine
+	ballots     map[string]ballot // voter ID to the ballot counted in change mode; owned by the processing goroutine
+	dirty       atomic.Bool       // counts changed since the last save to DataFile
+	ready       atomic.Bool       // vote processing is running; false before Start and once Stop begins
+	epoch       atomic.Uint64     // bumped by Reset; votes from an earlier epoch are discarded
+	creations   *tokenBucket      // Limits write-in candidate creation separately from voting
+	voteLimiter *ipLimiter        // Limits votes per client IP
+	polls       *PollRegistry     // Polls served under /polls/{id}; nil for the polls themselves
+	audit       *auditLog         // Per-vote audit log; nil unless AUDIT_LOG_FILE is set
+
+	candidatesCreated  atomic.Uint64
+	creationsThrottled atomic.Uint64
+	votesThrottled     atomic.Uint64
+
+	// Reputation optionally weights each identified vote; set it before Start
+	Reputation ReputationFunc
+
+	// NotifyVoter optionally confirms each counted identified vote; set it before Start
+	NotifyVoter VoterNotifier
+
+	// Checks are the startup self-check results served by /readyz
+	Checks []CheckResult
+}
+
+// clientOptions holds the per-client stream preferences
+type clientOptions struct {
+	sort      string // ?sort order applied to every snapshot sent to this client
+	composite bool   // ?composite=true adds the changed candidates to each update
+}
+
+// client is a registered SSE connection
+type client struct {
+	opts    clientOptions
+	session *Session
+	misses  int // consecutive events dropped because the channel was full
+}
+
+// cliRequest represents a request to modify or inspect the clients
+type cliRequest struct {
+	clientChan chan sseEvent
+	client     *client
+	sessionID  string
+	action     string        // "add", "remove", "list", "count", "revoke", "send" or "snapshot"
+	reply      chan cliReply // answers "add", "list", "count" and "revoke"
+
+	// event is fanned out by "send"; "snapshot" sends snapshot under event's ID and type in each client's
+	// order, with delta added for composite clients
+	event    sseEvent
+	snapshot ResultsPayload
+	delta    []*Candidate
+}
+
+// cliReply carries the answer to an "add", "list", "count" or "revoke" request
+type cliReply struct {
+	sessions []SessionInfo
+	count    int
+	found    bool
+	full     bool // "add" was refused because MaxSSEClients are connected
+}
+
+// NewVoteManager initializes and returns a VoteManager serving the default poll. It fails if
+// the candidates file is invalid. Polls created through POST /admin/polls are started and
+// stopped along with it.
+func NewVoteManager(cfg Config) (*VoteManager, error) {
+	vm, err := newVoteManager(defaultPollID, cfg)
+	if err != nil {
+		return nil, err
+	}
+	vm.polls = newPollRegistry(cfg, vm)
+	return vm, nil
+}
+
+// newVoteManager initializes a VoteManager for the poll with the given ID
+func newVoteManager(id string, cfg Config) (*VoteManager, error) {
+	candidates, err := initialCandidates(cfg)
+	if err != nil {
+		return nil, err
+	}
+	vm := &VoteManager{
+		candidates:  candidates,
+		voteChannel: make(chan vote, cfg.voteBufferSize()), // Buffered channel for votes
+		clients:     make(map[chan sseEvent]*client),
+		cliRequests: make(chan cliRequest), // Channel for client management
+		stopClients: make(chan struct{}),
+		clientsDone: make(chan struct{}),
+		cfg:         cfg,
+		picks:       newVoterPicks(cfg.Poll.MaxPicks),
+		ops:         make(chan func()),
+		done:        make(chan struct{}),
+		clock:       realClock{},
+		history:     voteHistory{max: cfg.HistorySize},
+		tokens:      sessionTokens{tokens: make(map[string]tokenEntry)},
+		votedBy:     make(map[string]string),
+		ballots:     make(map[string]ballot),
+		replay:      eventRing{size: cfg.SSEReplayBuffer},
+		metrics:     newMetrics(id),
+		creations:   newTokenBucket(cfg.CandidateCreateRate, cfg.CandidateCreateBurst),
+		voteLimiter: newIPLimiter(cfg.VoteRate, cfg.VoteBurst),
+		throttle: candidateThrottle{
+			lastSent: make(map[string]time.Time),
+			pending:  make(map[string]bool),
+		},
+	}
+	if cfg.DataFile != "" {
+		vm.loadSaved()
+	}
+	vm.lastCounts.changed(vm.candidateList())
+	if cfg.AuditLogFile != "" {
+		audit, err := openAuditLog(cfg.AuditLogFile)
+		if err != nil {
+			slog.Error("Failed to open audit log, votes will not be audited", "error", err)
+		} else {
+			vm.audit = audit
+		}
+	}
+	go vm.manageClients() // Start the client management goroutine
+	return vm, nil
+}
+
+// Start begins processing votes
+func (vm *VoteManager) Start(ctx context.Context) {
+	if vm.polls != nil {
+		vm.polls.setContext(ctx)
+	}
+	vm.wg.Add(1)
+	go func() {
+		defer vm.wg.Done()
+		defer close(vm.done)
+		// With coalescing on, updates go out on this tick instead of per vote
+		var flush <-chan time.Time
+		if vm.cfg.BroadcastInterval > 0 {
+			ticker := time.NewTicker(vm.cfg.BroadcastInterval)
+			defer ticker.Stop()
+			flush = ticker.C
+		}
+		for {
+			select {
+			case v, ok := <-vm.voteChannel:
+				if !ok {
+					vm.flushUpdate()
+					return
+				}
+				vm.processVote(v)
+			case <-flush:
+				vm.flushUpdate()
+			case op := <-vm.ops:
+				vm.drainVotes()
+				op()
+			case <-ctx.Done():
+				// Votes accepted before cancellation still count
+				vm.drainVotes()
+				vm.flushUpdate()
+				return
+			}
+		}
+	}()
+	vm.scheduleOpen()
+	vm.scheduleClose()
+	vm.ready.Store(true)
+	if vm.cfg.DataFile != "" && !vm.cfg.ReadOnly {
+		go vm.runSaver()
+	}
+	if vm.cfg.ExportURL != "" {
+		go vm.runExporter(ctx)
+	}
+	if vm.audit != nil {
+		go vm.runAuditFlusher()
+	}
+}
+
+// drainVotes applies every vote currently buffered in voteChannel without blocking
+func (vm *VoteManager) drainVotes() {
+	for {
+		select {
+		case v, ok := <-vm.voteChannel:
+			if !ok {
+				return
+			}
+			vm.processVote(v)
+		default:
+			return
+		}
+	}
+}
+
+// do runs fn on the vote-processing goroutine once buffered votes are applied, and waits for it.
+// It reports false if the goroutine has already stopped.
+func (vm *VoteManager) do(fn func()) bool {
+	finished := make(chan struct{})
+	select {
+	case vm.ops <- func() { fn(); close(finished) }:
+	case <-vm.done:
+		return false
+	}
+	<-finished
+	return true
+}
+
+func (vm *VoteManager) processVote(v vote) {
+	span := startVoteSpan(v)
+	defer span.End()
+	if v.epoch != vm.epoch.Load() {
+		slog.Info("Discarding vote admitted before a reset", "candidate", v.candidate)
+		failSpan(span, "vote admitted before a reset")
+		v.answer(nil)
+		return
+	}
+	weight := v.weight * vm.voteWeight(v.identity)
+	now := vm.clock.Now()
+
+	vm.mu.Lock()
+	candidate, exists := vm.candidates[v.candidate]
+	created := false
+	if !exists && vm.cfg.AutoCreateCandidates && len(vm.candidates) < vm.cfg.MaxCandidates {
+		candidate = &Candidate{Name: v.candidate}
+		vm.candidates[v.candidate] = candidate
+		exists, created = true, true
+		vm.candidatesCreated.Add(1)
+		vm.metrics.candidatesCreated.Inc()
+		slog.Info("Auto-created candidate", "candidate", v.candidate)
+	}
+	var retracted *Candidate
+	var taken, votes int
+	if exists {
+		vm.countVote(candidate, now, weight)
+		retracted, taken = vm.retractVote(v, now, weight)
+		votes = candidate.Votes
+	}
+	vm.mu.Unlock()
+
+	if !exists {
+		slog.Warn("Received vote for unknown candidate", "candidate", v.candidate)
+		failSpan(span, errCandidateNotFound.Error())
+		v.answer(nil)
+		return
+	}
+	votes, _ = capped(votes, vm.cfg.ResultsDisplayCap)
+	v.answer(&VoteResult{Candidate: v.candidate, Votes: votes})
+	slog.Debug("Vote received", "candidate", v.candidate, "weight", weight)
+	vm.history.add(now, v.candidate, weight)
+	vm.metrics.votes.WithLabelValues(v.candidate).Add(float64(weight))
+	vm.auditVote(v, now, weight)
+	vm.notifyVoter(v)
+	if retracted != nil {
+		vm.auditRetraction(v, retracted.Name, now, taken)
+		vm.notifyCandidate(retracted)
+	}
+	if created {
+		// A new candidate is announced right away rather than throttled
+		vm.notifyClients(eventCandidateAdded)
+	} else {
+		vm.notifyCandidate(candidate)
+	}
+	vm.checkMilestone(candidate)
+}
+
+// countVote adds a vote of the given weight to candidate; callers hold vm.mu. Only votes cast
+// count towards the rate, not retracted or undone ones.
+func (vm *VoteManager) countVote(candidate *Candidate, now time.Time, weight int) {
+	candidate.Votes += weight
+	vm.dirty.Store(true)
+	if vm.cfg.DecayHalfLife > 0 {
+		candidate.decay.add(now, vm.cfg.DecayHalfLife, float64(weight))
+	}
+	if weight > 0 {
+		candidate.rate.add(now, weight)
+	}
+}
+
+// manageClients handles adding and removing client channels until Stop, then closes every
+// remaining client channel itself so no other goroutine ever touches the map
+func (vm *VoteManager) manageClients() {
+	defer close(vm.clientsDone)
+	for {
+		var req cliRequest
+		select {
+		case req = <-vm.cliRequests:
+		case <-vm.stopClients:
+			for clientChan := range vm.clients {
+				// Make room if the client is behind: nothing buffered matters once the stream ends
+				select {
+				case <-clientChan:
+				default:
+				}
+				clientChan <- shutdownEvent
+				close(clientChan)
+				delete(vm.clients, clientChan)
+			}
+			vm.setClientCount()
+			return
+		}
+		switch req.action {
+		case "add":
+			// Checked here, where the map is owned, so concurrent connects cannot both slip past
+			if limit := vm.cfg.MaxSSEClients; limit > 0 && len(vm.clients) >= limit {
+				req.reply <- cliReply{full: true}
+				continue
+			}
+			// Broadcasts skipped while nobody listened left the delta baseline stale; rebase it on
+			// the current counts, which the new client's snapshot reflects, before the count rises
+			if len(vm.clients) == 0 {
+				vm.lastCounts.changed(vm.results())
+			}
+			vm.clients[req.clientChan] = req.client
+			vm.setClientCount()
+			slog.Debug("Client added", "session", req.client.session.ID, "client_count", len(vm.clients))
+			req.reply <- cliReply{}
+		case "remove":
+			// The channel may already be gone after a revoke
+			if c, ok := vm.clients[req.clientChan]; ok {
+				close(req.clientChan)
+				delete(vm.clients, req.clientChan)
+				vm.setClientCount()
+				slog.Debug("Client removed", "session", c.session.ID, "client_count", len(vm.clients))
+			}
+		case "list":
+			sessions := make([]SessionInfo, 0, len(vm.clients))
+			for _, c := range vm.clients {
+				sessions = append(sessions, c.session.info())
+			}
+			req.reply <- cliReply{sessions: sessions}
+		case "count":
+			req.reply <- cliReply{count: len(vm.clients)}
+		case "revoke":
+			found := false
+			for clientChan, c := range vm.clients {
+				if c.session.ID == req.sessionID {
+					close(clientChan)
+					delete(vm.clients, clientChan)
+					vm.setClientCount()
+					found = true
+					break
+				}
+			}
+			req.reply <- cliReply{found: found}
+		case "send":
+			for clientChan, c := range vm.clients {
+				vm.sendEvent(clientChan, c, req.event)
+			}
+		case "snapshot":
+			// Encode once per set of options in use rather than once per client
+			encoded := make(map[clientOptions]sseEvent)
+			for clientChan, c := range vm.clients {
+				ev, ok := encoded[c.opts]
+				if !ok {
+					payload := req.snapshot.sorted(c.opts.sort)
+					if c.opts.composite {
+						payload.Delta = req.delta
+					}
+					ev = sseEvent{ID: req.event.ID, Event: req.event.Event, Data: encodeResults(payload)}
+					encoded[c.opts] = ev
+				}
+				vm.sendEvent(clientChan, c, ev)
+			}
+		}
+	}
+}
+
+// setClientCount publishes len(clients) after a change; only manageClients calls it
+func (vm *VoteManager) setClientCount() {
+	vm.clientCount.Store(int64(len(vm.clients)))
+	vm.metrics.clientsConnected.Set(float64(len(vm.clients)))
+}
+
+// sendEvent queues ev for a client without blocking on a slow one. A client that misses
+// SlowClientMisses events in a row is disconnected rather than left silently out of sync;
+// its EventSource reconnects and catches up by replay or a fresh snapshot. It runs on
+// manageClients, which owns the client map.
+func (vm *VoteManager) sendEvent(clientChan chan sseEvent, c *client, ev sseEvent) {
+	select {
+	case clientChan <- ev:
+		c.misses = 0
+		return
+	default:
+	}
+	c.misses++
+	vm.metrics.messagesSkipped.Inc()
+	if vm.cfg.SlowClientMisses == 0 || c.misses < vm.cfg.SlowClientMisses {
+		slog.Warn("Skipping sending to a slow client", "session", c.session.ID, "event", ev.Event, "misses", c.misses)
+		return
+	}
+	slog.Warn("Disconnecting slow client", "session", c.session.ID, "misses", c.misses)
+	close(clientChan)
+	delete(vm.clients, clientChan)
+	vm.setClientCount()
+}
+
+// Stop gracefully stops the VoteManager. Every vote already accepted into voteChannel is counted,
+// broadcast and saved before it returns.
+func (vm *VoteManager) Stop() {
+	vm.ready.Store(false)
+	if vm.state.timer != nil {
+		vm.state.timer.Stop()
+	}
+	if vm.state.closer != nil {
+		vm.state.closer.Stop()
+	}
+
+	// Flag the shutdown under the write lock so no vote is mid-send when the channel closes
+	vm.state.mu.Lock()
+	vm.state.stopped = true
+	vm.state.mu.Unlock()
+	close(vm.voteChannel)
+	vm.wg.Wait()
+
+	// The loop may have exited on ctx before the channel closed; the votes accepted since
+	// are applied here, now that no other goroutine processes them
+	vm.drainVotes()
+	vm.throttle.updatePending = false
+	vm.notifyClients(eventUpdate)
+	if vm.audit != nil {
+		vm.audit.close()
+	}
+
+	// Every vote is counted now; a read-only server never changes the counts
+	if vm.cfg.DataFile != "" && !vm.cfg.ReadOnly {
+		if err := vm.Save(vm.cfg.DataFile); err != nil {
+			slog.Error("Failed to save vote counts", "error", err)
+		}
+	}
+
+	vm.disconnectClients()
+	if vm.polls != nil {
+		vm.polls.Stop()
+	}
+}
+
+// DisconnectClients fails readiness, then sends every SSE client of every poll a close event,
+// ends its stream and refuses new ones. Call it before shutting the HTTP server down, since
+// open streams would otherwise hold Shutdown until its timeout; Stop calls it too.
+func (vm *VoteManager) DisconnectClients() {
+	vm.ready.Store(false)
+	if vm.polls != nil {
+		vm.polls.DisconnectClients()
+	}
+	vm.disconnectClients()
+}
+
+// disconnectClients ends this poll's SSE streams and WebSocket connections
+func (vm *VoteManager) disconnectClients() {
+	vm.stopOnce.Do(func() { close(vm.stopClients) })
+	<-vm.clientsDone
+	vm.sockets.closeAndWait()
+}
+
+// results returns a copy of the candidates ordered by name, shared by /results and the SSE snapshot.
+// Candidates without votes are always listed at zero, and the slice is never nil, so clients can
+// render an empty poll from the snapshot alone. Counts above RESULTS_DISPLAY_CAP are capped.
+func (vm *VoteManager) results() []*Candidate {
+	candidateList := vm.candidateList()
+	applyDisplayCap(candidateList, vm.cfg.ResultsDisplayCap)
+	applyPercentages(candidateList)
+	if vm.cfg.ResultsBasisPoints {
+		applyBasisPoints(candidateList)
+	}
+	return candidateList
+}
+
+// exactResults is results without the display cap, for admins
+func (vm *VoteManager) exactResults() []*Candidate {
+	candidateList := vm.candidateList()
+	applyPercentages(candidateList)
+	if vm.cfg.ResultsBasisPoints {
+		applyBasisPoints(candidateList)
+	}
+	return candidateList
+}
+
+// candidateList copies the candidates ordered by name
+func (vm *VoteManager) candidateList() []*Candidate {
+	vm.mu.RLock()
+	defer vm.mu.RUnlock()
+	now := vm.clock.Now()
+	candidateList := make([]*Candidate, 0, len(vm.candidates))
+	for _, candidate := range vm.candidates {
+		c := &Candidate{
+			Name:          candidate.Name,
+			Votes:         candidate.Votes,
+			Rate:          candidate.rate.valueAt(now),
+			CandidateInfo: candidate.CandidateInfo,
+		}
+		if vm.cfg.DecayHalfLife > 0 {
+			decayed := roundDecayed(candidate.decay.valueAt(now, vm.cfg.DecayHalfLife))
+			c.DecayedVotes = &decayed
+		}
+		candidateList = append(candidateList, c)
+	}
+	sortCandidates(candidateList, sortByName)
+	return candidateList
+}
+
+// snapshot returns the current results with their total, shared by /results and the stream so
+// the two can never drift
+func (vm *VoteManager) snapshot() ResultsPayload {
+	return newResultsPayload(vm.results())
+}
+
+// newResultsPayload wraps candidates with their total
+func newResultsPayload(candidates []*Candidate) ResultsPayload {
+	total := 0
+	for _, c := range candidates {
+		total += c.Votes
+	}
+	return ResultsPayload{Candidates: candidates, Total: total}
+}
+
+// sorted returns a copy of s with its candidates in the given ?sort order
+func (s ResultsPayload) sorted(mode string) ResultsPayload {
+	s.Candidates = slices.Clone(s.Candidates)
+	sortCandidates(s.Candidates, mode)
+	return s
+}
+
+// notifyClients sends the full results to all connected clients as an event of the given type
+func (vm *VoteManager) notifyClients(event string) {
+	span := startSpan("notifyClients", attrEvent.String(event))
+	defer span.End()
+	span.SetAttributes(attrClients.Int64(vm.clientCount.Load()))
+	// Nobody is listening, so skip the work during quiet periods
+	if vm.clientCount.Load() == 0 {
+		vm.replay.skip(&vm.seq)
+		vm.changes.notify()
+		return
+	}
+	snapshot := vm.snapshot()
+	delta := vm.lastCounts.changed(snapshot.Candidates)
+	ev, err := vm.replay.record(&vm.seq, vm.clock.Now(), sseEvent{Event: event}, &snapshot)
+	vm.changes.notify()
+	if err != nil {
+		slog.Error("Failed to record results event", "event", event, "error", err)
+		return
+	}
+	// manageClients owns the client map, so it does the fan-out
+	vm.clientRequest(cliRequest{action: "snapshot", event: ev, snapshot: snapshot, delta: delta})
+}
+
+// countsSnapshot remembers each candidate's count at the previous broadcast
+type countsSnapshot struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// changed returns the candidates whose count differs from the previous call, or which are new,
+// and remembers the current counts
+func (s *countsSnapshot) changed(candidates []*Candidate) []*Candidate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var delta []*Candidate
+	counts := make(map[string]int, len(candidates))
+	for _, c := range candidates {
+		counts[c.Name] = c.Votes
+		if votes, ok := s.counts[c.Name]; !ok || votes != c.Votes {
+			delta = append(delta, c)
+		}
+	}
+	s.counts = counts
+	return delta
+}
+
+// broadcast sends payload as an event of the given type to all connected clients
+func (vm *VoteManager) broadcast(event string, payload broadcastPayload) {
+	ev, err := vm.replay.record(&vm.seq, vm.clock.Now(), sseEvent{Event: event}, payload)
+	vm.changes.notify()
+	if err != nil {
+		slog.Error("Failed to marshal event", "event", event, "error", err)
+		return
+	}
+	vm.clientRequest(cliRequest{action: "send", event: ev})
+}
+
+// AddClient registers a new client channel. It returns errTooManyClients when MaxSSEClients are
+// already connected and errStopped once the manager has shut down.
+func (vm *VoteManager) AddClient(clientChan chan sseEvent, opts clientOptions, session *Session) error {
+	reply, err := vm.clientRequest(cliRequest{clientChan: clientChan, client: &client{opts: opts, session: session}, action: "add", reply: make(chan cliReply, 1)})
+	if err != nil {
+		return err
+	}
+	if reply.full {
+		return errTooManyClients
+	}
+	return nil
+}
+
+// RemoveClient unregisters a client channel; after shutdown the channel is already closed
+func (vm *VoteManager) RemoveClient(clientChan chan sseEvent) {
+	vm.clientRequest(cliRequest{clientChan: clientChan, action: "remove"})
+}
+
+// ClientCount returns the number of connected SSE clients as seen by manageClients, which
+// owns the client map; it is 0 once the manager has shut down
+func (vm *VoteManager) ClientCount() int {
+	reply, err := vm.clientRequest(cliRequest{action: "count", reply: make(chan cliReply, 1)})
+	if err != nil {
+		return 0
+	}
+	return reply.count
+}
+
+// clientRequest hands req to manageClients and waits for its reply, if it has one.
+// It returns errStopped instead of blocking once manageClients has exited.
+func (vm *VoteManager) clientRequest(req cliRequest) (cliReply, error) {
+	select {
+	case vm.cliRequests <- req:
+	case <-vm.clientsDone:
+		return cliReply{}, errStopped
+	}
+	if req.reply == nil {
+		return cliReply{}, nil
+	}
+	return <-req.reply, nil
+}
+
+// admitVote claims a ballot for v and queues it for processing, releasing the claim again if
+// the vote is refused. On errAlreadyVoted, v.previous holds the voter's existing choice.
+func (vm *VoteManager) admitVote(v *vote) error {
+	// Capture the epoch before claiming a ballot, so a reset in between discards the vote
+	v.epoch = vm.epoch.Load()
+	if err := vm.picks.record(v.voter, v.candidate); err != nil {
+		return err
+	}
+	previous, err := vm.claimBallot(v.voter, v.candidate)
+	v.previous = previous
+	if err != nil {
+		vm.picks.release(v.voter, v.candidate)
+		return err
+	}
+	err = vm.enqueueVote(*v)
+	if err == nil || err == errPreVoteQueued {
+		return err
+	}
+	if err == errShedding || err == errBusy {
+		vm.metrics.votesDropped.Inc()
+	}
+	vm.picks.release(v.voter, v.candidate)
+	vm.releaseBallot(v.voter, v.candidate, previous)
+	return err
+}
+
+// voteHandler accepts votes for registered candidates, answering 400 for empty or over-long
+// names and 404 for candidates that are not registered (unless write-ins are on)
+func (vm *VoteManager) voteHandler(w http.ResponseWriter, r *http.Request) {
+	span := startRequestSpan(r, "voteHandler")
+	defer span.End()
+	if vm.cfg.ReadOnly {
+		writeJSONError(w, http.StatusServiceUnavailable, "Voting is unavailable: server is read-only")
+		return
+	}
+	req, status, err := parseVoteRequest(w, r)
+	if err != nil {
+		writeJSONError(w, status, err.Error())
+		return
+	}
+	// Trim at the boundary so accidental whitespace never forks a candidate
+	candidateName := strings.TrimSpace(req.Candidate)
+	span.SetAttributes(attrCandidate.String(candidateName))
+	if err := validateCandidateName(candidateName); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	weight, err := req.weight(vm.cfg.MaxVoteWeight)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !vm.hasCandidate(candidateName) {
+		if !vm.cfg.AutoCreateCandidates {
+			slog.Warn("Received vote for unknown candidate", "candidate", candidateName)
+			writeJSONError(w, http.StatusNotFound, "Unknown candidate")
+			return
+		}
+		if vm.cfg.CreateRequiresAdmin && !checkAdmin(w, r, vm.cfg.AdminToken) {
+			return
+		}
+		if vm.candidateCount() >= vm.cfg.MaxCandidates {
+			writeJSONError(w, http.StatusConflict, errCandidateLimit.Error())
+			return
+		}
+		if ok, wait := vm.creations.allow(vm.clock.Now()); !ok {
+			vm.creationsThrottled.Add(1)
+			w.Header().Set("Retry-After", retryAfter(wait))
+			writeJSONError(w, http.StatusTooManyRequests, "Too many new candidates, try again later")
+			return
+		}
+	}
+	voterID := req.Voter
+	if voterID == "" && vm.cfg.Poll.RequireVoterID {
+		writeJSONError(w, http.StatusBadRequest, "Voter ID is required for this poll")
+		return
+	}
+	if voterID == "" && vm.cfg.Poll.VoterMode != voterModeUnlimited {
+		voterID = voterCookie(w, r)
+	}
+	var reply chan VoteResult
+	if vm.cfg.SyncVotes {
+		reply = make(chan VoteResult, 1)
+	}
+	v := vote{candidate: candidateName, voter: voterID, identity: req.Voter, weight: weight, ip: clientIP(r, vm.cfg.TrustedProxies), reply: reply, span: span.SpanContext()}
+	err = vm.admitVote(&v)
+	// A full vote channel, or one past the shedding threshold, drops the vote
+	span.SetAttributes(attrDropped.Bool(err == errShedding || err == errBusy))
+	if err != nil && err != errPreVoteQueued {
+		failSpan(span, err.Error())
+	}
+	switch err {
+	case nil:
+		vm.awaitVote(w, r, reply)
+	case errPreVoteQueued:
+		w.WriteHeader(http.StatusAccepted)
+	case errAlreadyPicked, errPickLimit:
+		writeJSONError(w, http.StatusConflict, err.Error())
+	case errAlreadyVoted:
+		writeJSON(w, http.StatusConflict, AlreadyVoted{Error: err.Error(), Status: http.StatusConflict, Candidate: v.previous})
+	case errPollNotOpen, errPollClosed:
+		writeJSONError(w, http.StatusLocked, err.Error())
+	case errShedding:
+		w.Header().Set("Retry-After", "1")
+		writeJSONError(w, http.StatusTooManyRequests, err.Error())
+	default:
+		writeJSONError(w, http.StatusServiceUnavailable, err.Error())
+	}
+}
+
+// writeJSON encodes v into a buffer before writing, so an encoding failure yields a clean 500
+// instead of a partially written body
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		slog.Error("Failed to encode response", "error", err)
+		// Written as-is: encoding the error body must not be able to fail again
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, `{"error":"Failed to encode response","status":500}`+"\n")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// ErrorResponse is the JSON body of every error response; Status repeats the HTTP status code
+// and is left out of errors sent over /ws
+type ErrorResponse struct {
+	Error  string `json:"error"`
+	Status int    `json:"status,omitempty"`
+}
+
+// writeJSONError writes message as a JSON error body with the given status; every handler
+// reports errors through it rather than http.Error, so clients always get JSON
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, ErrorResponse{Error: message, Status: status})
+}
+
+// resultsHandler returns the current voting results
+func (vm *VoteManager) resultsHandler(w http.ResponseWriter, r *http.Request) {
+	mediaType, ok := negotiate(r.Header.Get("Accept"), "application/json", "text/csv")
+	if !ok {
+		writeJSONError(w, http.StatusNotAcceptable, "Supported media types: application/json, text/csv")
+		return
+	}
+	payload, err := vm.requestedResults(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if mediaType == "text/csv" || r.URL.Query().Get("format") == "csv" {
+		writeResultsCSV(w, payload)
+		return
+	}
+	writeJSON(w, http.StatusOK, payload)
+}
+
+// requestedResults returns the snapshot in the request's ?sort order
+func (vm *VoteManager) requestedResults(r *http.Request) (ResultsPayload, error) {
+	payload := vm.snapshot()
+	sortMode := r.URL.Query().Get("sort")
+	if err := sortCandidates(payload.Candidates, sortMode); err != nil {
+		return ResultsPayload{}, err
+	}
+	// An explicit ?sort still wins over shuffling
+	if vm.cfg.ResultsShuffle && sortMode == "" {
+		shuffleCandidates(payload.Candidates)
+	}
+	return payload, nil
+}
+
+// watermarkHandler returns the sequence number of the latest broadcast
+func (vm *VoteManager) watermarkHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]uint64{"seq": vm.seq.Load()})
+}
+
+// sseHandler handles Server-Sent Events (SSE) for real-time updates
+func (vm *VoteManager) sseHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	for name, value := range vm.cfg.SSEHeaders {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Connection", "keep-alive")
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSONError(w, http.StatusInternalServerError, "Streaming unsupported!")
+		return
+	}
+
+	// ?sort orders every snapshot on this stream like /results; the canonical form lets clients
+	// with the same order share one encoding
+	sortMode, err := canonicalSort(r.URL.Query().Get("sort"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// ?composite=true adds the candidates changed since the previous update to each update
+	composite, _ := strconv.ParseBool(r.URL.Query().Get("composite"))
+
+	session := newSession(clientIP(r, vm.cfg.TrustedProxies), vm.clock.Now())
+
+	// With token rotation on, ?session=<token> resumes a session and must still be valid
+	var token string
+	var rotate <-chan time.Time
+	if vm.cfg.SSETokenRotation > 0 {
+		if presented := r.URL.Query().Get("session"); presented != "" {
+			sessionID, ok := vm.tokens.lookup(presented, vm.clock.Now())
+			if !ok {
+				writeJSONError(w, http.StatusUnauthorized, "Invalid or expired session token")
+				return
+			}
+			session.ID = sessionID
+			vm.tokens.expire(presented, vm.clock.Now().Add(vm.cfg.SSETokenGrace))
+		}
+		token = vm.tokens.issue(session.ID)
+		rotate = vm.clock.After(vm.cfg.SSETokenRotation)
+		// Keep the latest token usable for a reconnect within the grace period
+		defer func() { vm.tokens.expire(token, vm.clock.Now().Add(vm.cfg.SSETokenGrace)) }()
+	}
+
+	clientChan := make(chan sseEvent, vm.cfg.clientBufferSize()) // Buffered to prevent blocking
+	switch err := vm.AddClient(clientChan, clientOptions{sort: sortMode, composite: composite}, session); err {
+	case nil:
+	case errTooManyClients:
+		w.Header().Set("Retry-After", retryAfter(sseFullRetry))
+		writeJSONError(w, http.StatusServiceUnavailable, "Too many connected clients, try again shortly")
+		return
+	default:
+		writeJSONError(w, http.StatusServiceUnavailable, "Server is shutting down")
+		return
+	}
+	defer vm.RemoveClient(clientChan)
+
+	// Every lifecycle log for this connection carries the same conn ID; the session ID
+	// survives token-rotation reconnects
+	connLog := slog.With("conn", newID(), "session", session.ID)
+	connLog.Info("SSE client connected", "remote_ip", session.RemoteIP, "sort", sortMode)
+	delivered := 0
+	reason := "client disconnected"
+	defer func() {
+		connLog.Info("SSE client disconnected", "reason", reason,
+			"duration", vm.clock.Now().Sub(session.ConnectedAt), "events", delivered)
+	}()
+
+	notify := r.Context().Done()
+
+	// Sent before any event so even the first reconnect waits the configured delay
+	if vm.cfg.SSERetryMS > 0 {
+		writeRetry(w, vm.cfg.SSERetryMS)
+	}
+
+	// A reconnecting EventSource sends Last-Event-ID; replay what it missed if it is still
+	// buffered, otherwise start with the full snapshot
+	cursor := &streamCursor{}
+	replayed, resumed := vm.resume(r.Header.Get("Last-Event-ID"))
+	if resumed {
+		for _, entry := range replayed {
+			ev := entry.event(sortMode)
+			cursor.seen(ev)
+			writeEvent(w, ev, vm.cfg.SSEFieldOrder)
+		}
+	} else {
+		// Read the ID first: updates after it may repeat in the snapshot, but none are skipped
+		cursor.last = vm.seq.Load()
+		initialSnapshot := vm.snapshot().sorted(sortMode)
+		initialSnapshot.setMeta(newEventMeta(cursor.last, vm.clock.Now()))
+		initial := sseEvent{ID: strconv.FormatUint(cursor.last, 10), Event: eventSnapshot, Data: encodeResults(initialSnapshot)}
+		writeEvent(w, initial, vm.cfg.SSEFieldOrder)
+	}
+	if token != "" {
+		writeEvent(w, sessionEvent(token), vm.cfg.SSEFieldOrder)
+	}
+	if !vm.bufferInitialUpdates(w, clientChan, notify, cursor) {
+		return
+	}
+	flusher.Flush()
+
+	pingTicker := time.NewTicker(vm.cfg.PingInterval)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+		case ev, ok := <-clientChan:
+			if !ok {
+				reason = "closed by server"
+				return
+			}
+			if cursor.seen(ev) {
+				continue
+			}
+			if err := writeEvent(w, ev, vm.cfg.SSEFieldOrder); err != nil {
+				reason = "write error: " + err.Error()
+				return
+			}
+			flusher.Flush()
+			if ev.Event == eventClose {
+				reason = "server shutdown"
+				return
+			}
+			session.touch(vm.clock.Now())
+			delivered++
+			if every := vm.cfg.SSELogEvery; every > 0 && delivered%every == 0 {
+				connLog.Info("SSE events delivered", "events", delivered)
+			}
+
+		case <-notify:
+			return
+
+		case <-rotate:
+			// The replaced token stays valid for the grace period so an in-flight reconnect still works
+			vm.tokens.expire(token, vm.clock.Now().Add(vm.cfg.SSETokenGrace))
+			token = vm.tokens.issue(session.ID)
+			if err := writeEvent(w, sessionEvent(token), vm.cfg.SSEFieldOrder); err != nil {
+				reason = "write error: " + err.Error()
+				return
+			}
+			flusher.Flush()
+			rotate = vm.clock.After(vm.cfg.SSETokenRotation)
+
+		case <-pingTicker.C:
+			if _, err := w.Write([]byte(":\n\n")); err != nil {
+				reason = "ping error: " + err.Error()
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// bufferInitialUpdates writes updates that arrive within SSEFlushDelay of connecting without flushing,
+// so they go out together with the snapshot. It reports false if the client went away.
+func (vm *VoteManager) bufferInitialUpdates(w io.Writer, clientChan chan sseEvent, done <-chan struct{}, cursor *streamCursor) bool {
+	if vm.cfg.SSEFlushDelay <= 0 {
+		return true
+	}
+	deadline := vm.clock.After(vm.cfg.SSEFlushDelay)
+	for {
+		select {
+		case ev, ok := <-clientChan:
+			if !ok {
+				return false
+			}
+			if cursor.seen(ev) {
+				continue
+			}
+			if err := writeEvent(w, ev, vm.cfg.SSEFieldOrder); err != nil {
+				slog.Info("Error writing to client", "error", err)
+				return false
+			}
+		case <-deadline:
+			return true
+		case <-done:
+			return false
+		}
+	}
+}
+
+// corsMiddleware adds CORS headers to responses. With "*" any origin is allowed; otherwise the
+// request's Origin is echoed back only when it is in the allowlist.
+func corsMiddleware(cors CORSConfig, next http.Handler) http.Handler {
+	wildcard := slices.Contains(cors.Origins, "*")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if wildcard {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			// The response depends on Origin, so caches must not share it across origins
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); origin != "" && slices.Contains(cors.Origins, origin) {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				if cors.AllowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+		}
+		w.Header().Set("Access-Control-Allow-Methods", corsAllowMethods)
+		w.Header().Set("Access-Control-Allow-Headers", corsAllowHeaders)
+
+		if r.Method == http.MethodOptions {
+			if cors.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(cors.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// timeoutBody is the JSON error timeoutMiddleware answers with
+const timeoutBody = `{"error":"Request timed out","status":503}`
+
+// timeoutMiddleware answers 503 when next takes longer than timeout; it must not wrap the SSE stream
+func timeoutMiddleware(timeout time.Duration, next http.Handler) http.Handler {
+	timeoutHandler := http.TimeoutHandler(next, timeout, timeoutBody)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeoutHandler.ServeHTTP(timeoutResponse{w}, r)
+	})
+}
+
+// timeoutResponse labels the body http.TimeoutHandler writes on a timeout as JSON. Responses
+// that finish in time arrive with their own headers already copied over.
+type timeoutResponse struct {
+	http.ResponseWriter
+}
+
+func (w timeoutResponse) WriteHeader(status int) {
+	if status == http.StatusServiceUnavailable && w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// securityHeadersMiddleware adds the configured security headers to responses
+func securityHeadersMiddleware(headers map[string]string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for name, value := range headers {
+			w.Header().Set(name, value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,468 @@
+package voting
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testAdminToken is the admin token testConfig sets
+const testAdminToken = "test-admin-token"
+
+// testConfig returns LoadConfig's defaults without the candidates file, so every test starts
+// from Candidate A and Candidate B
+func testConfig() Config {
+	cfg := LoadConfig()
+	cfg.CandidatesFile = ""
+	cfg.AdminToken = testAdminToken
+	return cfg
+}
+
+// newManager returns an unstarted VoteManager for cfg
+func newManager(tb testing.TB, cfg Config) *VoteManager {
+	tb.Helper()
+	vm, err := NewVoteManager(cfg)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return vm
+}
+
+// startManager returns a running VoteManager that is stopped when the test ends, unless the
+// test has stopped it itself
+func startManager(t *testing.T, cfg Config) *VoteManager {
+	t.Helper()
+	return startManagerWithClock(t, cfg, realClock{})
+}
+
+// startManagerWithClock is startManager with the VoteManager reading time from clock
+func startManagerWithClock(t *testing.T, cfg Config, clock Clock) *VoteManager {
+	t.Helper()
+	vm := newManager(t, cfg)
+	vm.clock = clock
+	ctx, cancel := context.WithCancel(context.Background())
+	vm.Start(ctx)
+	t.Cleanup(func() {
+		cancel()
+		vm.state.mu.RLock()
+		stopped := vm.state.stopped
+		vm.state.mu.RUnlock()
+		if !stopped {
+			vm.Stop()
+		}
+	})
+	return vm
+}
+
+// serve sends a request with an optional body through h and returns the recorded response
+func serve(h http.Handler, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, r)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// postVote votes for candidate through h
+func postVote(h http.Handler, candidate string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(map[string]string{"candidate": candidate})
+	return serve(h, http.MethodPost, "/vote", string(body))
+}
+
+// adminHeader is the Authorization header pair for serve
+var adminHeader = []string{"Authorization", "Bearer " + testAdminToken}
+
+// votesFor returns candidate's current count, or -1 if it does not exist
+func votesFor(vm *VoteManager, candidate string) int {
+	for _, c := range vm.candidateList() {
+		if c.Name == candidate {
+			return c.Votes
+		}
+	}
+	return -1
+}
+
+// waitFor fails the test unless cond becomes true within a few seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// decodeJSON unmarshals a recorded response body into v
+func decodeJSON(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("invalid JSON body %q: %v", rec.Body.String(), err)
+	}
+}
+
+// streamEvent is an event read off an SSE stream, with its raw field lines in order
+type streamEvent struct {
+	sseEvent
+	lines []string
+}
+
+// sseStream reads the events of an open SSE response
+type sseStream struct {
+	resp   *http.Response
+	events chan streamEvent
+	done   chan struct{}
+}
+
+// openStream connects to the SSE endpoint at url and closes the stream when the test ends
+func openStream(t *testing.T, url string) *sseStream {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		cancel()
+		resp.Body.Close()
+		t.Fatalf("GET %s: status %d", url, resp.StatusCode)
+	}
+	s := &sseStream{resp: resp, events: make(chan streamEvent), done: make(chan struct{})}
+	go s.read()
+	t.Cleanup(func() {
+		close(s.done)
+		cancel()
+		resp.Body.Close()
+	})
+	return s
+}
+
+// read parses the stream into events until it ends, skipping comments and retry-only blocks
+func (s *sseStream) read() {
+	defer close(s.events)
+	scanner := bufio.NewScanner(s.resp.Body)
+	var ev streamEvent
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			ev.lines = append(ev.lines, line)
+			field, value, _ := strings.Cut(line, ": ")
+			switch field {
+			case "id":
+				ev.ID = value
+			case "event":
+				ev.Event = value
+			case "data":
+				if ev.Data != "" {
+					ev.Data += "\n"
+				}
+				ev.Data += value
+			}
+			continue
+		}
+		if ev.Event != "" || ev.Data != "" {
+			select {
+			case s.events <- ev:
+			case <-s.done:
+				return
+			}
+		}
+		ev = streamEvent{}
+	}
+}
+
+// next returns the next event, failing the test if none arrives within a few seconds
+func (s *sseStream) next(t *testing.T) streamEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-s.events:
+		if !ok {
+			t.Fatal("stream ended")
+		}
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	return streamEvent{}
+}
+
+// nextOf skips events until one of type event arrives
+func (s *sseStream) nextOf(t *testing.T, event string) streamEvent {
+	t.Helper()
+	for {
+		if ev := s.next(t); ev.Event == event {
+			return ev
+		}
+	}
+}
+
+// results decodes the event's data as results
+func (ev streamEvent) results(t *testing.T) ResultsPayload {
+	t.Helper()
+	var p ResultsPayload
+	if err := json.Unmarshal([]byte(ev.Data), &p); err != nil {
+		t.Fatalf("invalid %s event data %q: %v", ev.Event, ev.Data, err)
+	}
+	return p
+}
+
+// newServer serves h over HTTP until the test ends
+func newServer(t *testing.T, h http.Handler) *httptest.Server {
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestPaddedVotesCountForTrimmedCandidate(t *testing.T) {
+	vm := startManager(t, testConfig())
+	h := vm.Handler()
+	for _, name := range []string{"Candidate A", " Candidate A", "Candidate A\t", "  Candidate A  "} {
+		if rec := postVote(h, name); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote for %q: status %d, body %s", name, rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "padded votes", func() bool { return votesFor(vm, "Candidate A") == 4 })
+	if n := len(vm.candidateList()); n != 2 {
+		t.Errorf("%d candidates after padded votes, want 2", n)
+	}
+}
+
+func TestWriteJSONEncodeFailureIsClean500(t *testing.T) {
+	rec := httptest.NewRecorder()
+	// NaN cannot be encoded; a streaming encoder would already have written the name
+	writeJSON(rec, http.StatusOK, struct {
+		Name  string  `json:"name"`
+		Score float64 `json:"score"`
+	}{"partial", math.NaN()})
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status %d, want 500", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "partial") {
+		t.Errorf("body %q contains the partial encoding", rec.Body)
+	}
+	var resp ErrorResponse
+	decodeJSON(t, rec, &resp)
+	if resp.Status != http.StatusInternalServerError || resp.Error == "" {
+		t.Errorf("error body %+v, want an error with status 500", resp)
+	}
+}
+
+func TestTimeoutMiddlewareAnswersSlowHandlers(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		io.WriteString(w, "too late")
+	})
+	rec := serve(timeoutMiddleware(10*time.Millisecond, slow), http.MethodGet, "/results", "")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status %d, want 503", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type %q, want application/json", ct)
+	}
+	var resp ErrorResponse
+	decodeJSON(t, rec, &resp)
+	if resp.Status != http.StatusServiceUnavailable {
+		t.Errorf("error body %+v, want status 503", resp)
+	}
+
+	fast := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "ok") })
+	if rec := serve(timeoutMiddleware(time.Second, fast), http.MethodGet, "/results", ""); rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("fast handler: status %d, body %q", rec.Code, rec.Body)
+	}
+}
+
+func TestStopRacingVotes(t *testing.T) {
+	cfg := testConfig()
+	cfg.VoteBuffer = 1000
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+
+	var wg sync.WaitGroup
+	var accepted atomic.Int64
+	start := make(chan struct{})
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for range 50 {
+				switch code := postVote(h, "Candidate A").Code; code {
+				case http.StatusAccepted:
+					accepted.Add(1)
+				case http.StatusServiceUnavailable:
+				default:
+					t.Errorf("vote during Stop: status %d, want 202 or 503", code)
+					return
+				}
+			}
+		}()
+	}
+	close(start)
+	vm.Stop()
+	wg.Wait()
+
+	// Every accepted vote was counted before Stop returned
+	if got := votesFor(vm, "Candidate A"); int64(got) != accepted.Load() {
+		t.Errorf("%d votes counted, %d accepted", got, accepted.Load())
+	}
+}
+
+func TestStopDrainsBufferedVotes(t *testing.T) {
+	const n = 500
+	cfg := testConfig()
+	cfg.VoteBuffer = n
+	cfg.DataFile = filepath.Join(t.TempDir(), "votes.json")
+	vm := startManager(t, cfg)
+	h := vm.Handler()
+
+	// Every vote waits in the channel when Stop begins
+	release := holdProcessing(vm)
+	for i := range n {
+		if rec := postVote(h, "Candidate A"); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote %d: status %d, body %s", i, rec.Code, rec.Body)
+		}
+	}
+	release()
+	vm.Stop()
+
+	if got := votesFor(vm, "Candidate A"); got != n {
+		t.Errorf("%d votes counted after Stop, want %d", got, n)
+	}
+	data, err := os.ReadFile(cfg.DataFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved savedState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	i := slices.IndexFunc(saved.Candidates, func(c savedCandidate) bool { return c.Name == "Candidate A" })
+	if i < 0 || saved.Candidates[i].Votes != n {
+		t.Errorf("final save %s, want Candidate A at %d votes", data, n)
+	}
+}
+
+// votes returns candidate's count in the payload, or -1 if it is not listed
+func (s ResultsPayload) votes(candidate string) int {
+	for _, c := range s.Candidates {
+		if c.Name == candidate {
+			return c.Votes
+		}
+	}
+	return -1
+}
+
+func TestVotesCountWithNoSubscribers(t *testing.T) {
+	vm := startManager(t, testConfig())
+	if n := vm.ClientCount(); n != 0 {
+		t.Fatalf("%d clients connected, want none", n)
+	}
+	for range 3 {
+		if rec := postVote(vm.Handler(), "Candidate A"); rec.Code != http.StatusAccepted {
+			t.Fatalf("vote: status %d, body %s", rec.Code, rec.Body)
+		}
+	}
+	waitFor(t, "votes", func() bool { return votesFor(vm, "Candidate A") == 3 })
+}
+
+// BenchmarkNotifyClients compares a broadcast with nobody listening, which skips encoding the
+// results, to one with a single subscriber
+func BenchmarkNotifyClients(b *testing.B) {
+	for _, subscribers := range []int{0, 1} {
+		b.Run(fmt.Sprintf("subscribers=%d", subscribers), func(b *testing.B) {
+			vm := newManager(b, testConfig())
+			ctx, cancel := context.WithCancel(context.Background())
+			vm.Start(ctx)
+			defer func() {
+				cancel()
+				vm.Stop()
+			}()
+			for range subscribers {
+				ch := make(chan sseEvent, 16)
+				if err := vm.AddClient(ch, clientOptions{}, &Session{ID: newID()}); err != nil {
+					b.Fatal(err)
+				}
+				go func() {
+					for range ch {
+					}
+				}()
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				vm.do(func() { vm.notifyClients(eventUpdate) })
+			}
+		})
+	}
+}
+
+func TestAddClientDuringStop(t *testing.T) {
+	vm := startManager(t, testConfig())
+
+	var adders, drained sync.WaitGroup
+	start := make(chan struct{})
+	for range 8 {
+		adders.Add(1)
+		go func() {
+			defer adders.Done()
+			<-start
+			for {
+				ch := make(chan sseEvent, 4)
+				if err := vm.AddClient(ch, clientOptions{}, &Session{ID: newID()}); err != nil {
+					return // shut down: later clients are turned away
+				}
+				// Every client admitted before shutdown must have its channel closed
+				drained.Add(1)
+				go func() {
+					defer drained.Done()
+					for range ch {
+					}
+				}()
+			}
+		}()
+	}
+
+	close(start)
+	stopped := make(chan struct{})
+	go func() {
+		vm.Stop()
+		adders.Wait()
+		drained.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop, AddClient or a client drain deadlocked")
+	}
+	if err := vm.AddClient(make(chan sseEvent), clientOptions{}, &Session{}); err == nil {
+		t.Error("AddClient succeeded after Stop")
+	}
+}
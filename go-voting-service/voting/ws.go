@@ -0,0 +1,307 @@
+package voting
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsWriteTimeout bounds each write to a WebSocket client
+const wsWriteTimeout = 10 * time.Second
+
+// Event types sent only over /ws, in reply to a client's vote
+const (
+	eventVoteAccepted = "vote_accepted"
+	eventError        = "error"
+)
+
+var (
+	errTooManyVotes    = errors.New("too many votes from this address")
+	errReadOnly        = errors.New("voting is unavailable: server is read-only")
+	errVoterIDRequired = errors.New("voter ID is required for this poll")
+)
+
+// WSMessage is every message /ws sends. Event and ID match the SSE event type and id line, and
+// Data is the same JSON payload.
+type WSMessage struct {
+	Event string          `json:"event"`
+	ID    string          `json:"id,omitempty"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// WSRequest is a message accepted on /ws; "vote" is the only action
+type WSRequest struct {
+	Action string `json:"action"`
+	VoteRequest
+}
+
+// socketTracker counts open /ws connections. Shutdown does not track hijacked connections, so
+// disconnectClients waits on these itself; once it has begun, no new connection is admitted,
+// so the count never rises from zero while it waits.
+type socketTracker struct {
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// add admits a connection, reporting false once closeAndWait has begun
+func (s *socketTracker) add() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.wg.Add(1)
+	return true
+}
+
+// done releases a connection admitted by add
+func (s *socketTracker) done() {
+	s.wg.Done()
+}
+
+// closeAndWait refuses new connections and waits for the open ones to end
+func (s *socketTracker) closeAndWait() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// wsUpgrader returns an upgrader accepting the same origins as corsMiddleware. Requests without
+// an Origin come from native clients and are always accepted.
+func wsUpgrader(cors CORSConfig) *websocket.Upgrader {
+	wildcard := slices.Contains(cors.Origins, "*")
+	return &websocket.Upgrader{
+		CheckOrigin: func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return wildcard || origin == "" || slices.Contains(cors.Origins, origin)
+		},
+	}
+}
+
+// wsMessage converts a stream event into the message sent over /ws
+func wsMessage(ev sseEvent) WSMessage {
+	return WSMessage{Event: ev.Event, ID: ev.ID, Data: json.RawMessage(ev.Data)}
+}
+
+// wsReply builds a reply to one of the client's own messages
+func wsReply(event string, payload any) WSMessage {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		slog.Error("Failed to marshal WebSocket reply", "error", err)
+	}
+	return WSMessage{Event: event, Data: data}
+}
+
+// wsHandler streams the same events as /events over a WebSocket and accepts votes on it. The
+// client is registered with manageClients like an SSE client, so both get every broadcast.
+// Pings go out every PingInterval and the connection is dropped when no pong follows in time.
+func (vm *VoteManager) wsHandler(w http.ResponseWriter, r *http.Request) {
+	sortMode, err := canonicalSort(r.URL.Query().Get("sort"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	composite, _ := strconv.ParseBool(r.URL.Query().Get("composite"))
+	session := newSession(clientIP(r, vm.cfg.TrustedProxies), vm.clock.Now())
+
+	if !vm.sockets.add() {
+		writeJSONError(w, http.StatusServiceUnavailable, "Server is shutting down")
+		return
+	}
+	defer vm.sockets.done()
+
+	clientChan := make(chan sseEvent, vm.cfg.clientBufferSize())
+	switch err := vm.AddClient(clientChan, clientOptions{sort: sortMode, composite: composite}, session); err {
+	case nil:
+	case errTooManyClients:
+		w.Header().Set("Retry-After", retryAfter(sseFullRetry))
+		writeJSONError(w, http.StatusServiceUnavailable, "Too many connected clients, try again shortly")
+		return
+	default:
+		writeJSONError(w, http.StatusServiceUnavailable, "Server is shutting down")
+		return
+	}
+	defer vm.RemoveClient(clientChan)
+
+	// Anonymous voters are keyed by the voter cookie, as on /vote, so reconnecting never grants
+	// a fresh ballot. The upgrader writes its own response, so a new cookie goes in its header.
+	var voterKey string
+	upgradeHeader := http.Header{}
+	if vm.cfg.Poll.VoterMode != voterModeUnlimited {
+		var cookie *http.Cookie
+		if voterKey, cookie = readVoterCookie(r); cookie != nil {
+			upgradeHeader.Add("Set-Cookie", cookie.String())
+		}
+	}
+
+	// The upgrader has already answered the client when it fails
+	conn, err := wsUpgrader(vm.cfg.CORS).Upgrade(w, r, upgradeHeader)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	connLog := slog.With("conn", newID(), "session", session.ID)
+	connLog.Info("WebSocket client connected", "remote_ip", session.RemoteIP, "sort", sortMode)
+	reason := "client disconnected"
+	defer func() {
+		connLog.Info("WebSocket client disconnected", "reason", reason,
+			"duration", vm.clock.Now().Sub(session.ConnectedAt))
+	}()
+
+	// Only this goroutine writes; the reader hands its replies over on replies
+	replies := make(chan WSMessage, 1)
+	readDone := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+	go vm.wsRead(conn, r, voterKey, replies, readDone, stop)
+
+	write := func(msg WSMessage) bool {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		if err := conn.WriteJSON(msg); err != nil {
+			reason = "write error: " + err.Error()
+			return false
+		}
+		return true
+	}
+
+	// Read the ID first: updates after it may repeat in the snapshot, but none are skipped
+	cursor := &streamCursor{last: vm.seq.Load()}
+	initial := vm.snapshot().sorted(sortMode)
+	initial.setMeta(newEventMeta(cursor.last, vm.clock.Now()))
+	msg := wsReply(eventSnapshot, initial)
+	msg.ID = strconv.FormatUint(cursor.last, 10)
+	if !write(msg) {
+		return
+	}
+
+	pingTicker := time.NewTicker(vm.cfg.PingInterval)
+	defer pingTicker.Stop()
+
+	for {
+		select {
+		case ev, ok := <-clientChan:
+			if !ok {
+				reason = "closed by server"
+				vm.wsClose(conn, websocket.CloseGoingAway, reason)
+				return
+			}
+			if cursor.seen(ev) {
+				continue
+			}
+			if !write(wsMessage(ev)) {
+				return
+			}
+			if ev.Event == eventClose {
+				reason = "server shutdown"
+				vm.wsClose(conn, websocket.CloseGoingAway, reason)
+				return
+			}
+			session.touch(vm.clock.Now())
+
+		case msg := <-replies:
+			if !write(msg) {
+				return
+			}
+
+		case <-readDone:
+			return
+
+		case <-pingTicker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				reason = "ping error: " + err.Error()
+				return
+			}
+		}
+	}
+}
+
+// wsClose tells the client why the server is ending the connection
+func (vm *VoteManager) wsClose(conn *websocket.Conn, code int, reason string) {
+	conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(wsWriteTimeout))
+}
+
+// wsRead reads the client's messages until the connection fails or no pong arrives within two
+// ping intervals, then closes done. It gives up on replies once the handler closes stop.
+func (vm *VoteManager) wsRead(conn *websocket.Conn, r *http.Request, voterKey string, replies chan<- WSMessage, done, stop chan struct{}) {
+	defer close(done)
+	conn.SetReadLimit(maxVoteBodyBytes)
+	deadline := 2 * vm.cfg.PingInterval
+	conn.SetReadDeadline(time.Now().Add(deadline))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(deadline))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(deadline))
+
+		var reply WSMessage
+		var req WSRequest
+		switch err := json.Unmarshal(data, &req); {
+		case err != nil:
+			reply = wsReply(eventError, ErrorResponse{Error: errInvalidVoteBody.Error()})
+		case req.Action != "vote":
+			reply = wsReply(eventError, ErrorResponse{Error: "unknown action"})
+		default:
+			if err := vm.wsVote(r, voterKey, req.VoteRequest); err != nil {
+				reply = wsReply(eventError, ErrorResponse{Error: err.Error()})
+			} else {
+				reply = wsReply(eventVoteAccepted, map[string]string{"candidate": strings.TrimSpace(req.Candidate)})
+			}
+		}
+		select {
+		case replies <- reply:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// wsVote checks and queues a vote sent over /ws under the same rules as /vote. Write-ins are not
+// created here, and without a voter ID voterKey, the connection's voter cookie, identifies the voter.
+func (vm *VoteManager) wsVote(r *http.Request, voterKey string, req VoteRequest) error {
+	ip := clientIP(r, vm.cfg.TrustedProxies)
+	if ok, _ := vm.voteLimiter.allow(ip, vm.clock.Now()); !ok {
+		vm.votesThrottled.Add(1)
+		return errTooManyVotes
+	}
+	if vm.cfg.ReadOnly {
+		return errReadOnly
+	}
+	name := strings.TrimSpace(req.Candidate)
+	if err := validateCandidateName(name); err != nil {
+		return err
+	}
+	if !vm.hasCandidate(name) {
+		return errCandidateNotFound
+	}
+	weight, err := req.weight(vm.cfg.MaxVoteWeight)
+	if err != nil {
+		return err
+	}
+	voterID := req.Voter
+	if voterID == "" && vm.cfg.Poll.RequireVoterID {
+		return errVoterIDRequired
+	}
+	if voterID == "" && vm.cfg.Poll.VoterMode != voterModeUnlimited {
+		voterID = voterKey
+	}
+	err = vm.admitVote(&vote{candidate: name, voter: voterID, identity: req.Voter, weight: weight, ip: ip})
+	if err == errPreVoteQueued {
+		return nil
+	}
+	return err
+}
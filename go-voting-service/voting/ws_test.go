@@ -0,0 +1,57 @@
+package voting
+
+import (
+	"net/http/cookiejar"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsVoteReply dials /ws with dialer, sends one vote and returns the reply to it
+func wsVoteReply(t *testing.T, dialer *websocket.Dialer, url, candidate string) WSMessage {
+	t.Helper()
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := conn.WriteJSON(map[string]string{"action": "vote", "candidate": candidate}); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		var msg WSMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Event == eventVoteAccepted || msg.Event == eventError {
+			return msg
+		}
+	}
+}
+
+func TestWSReconnectKeepsVoter(t *testing.T) {
+	cfg := testConfig()
+	cfg.Poll.VoterMode = voterModeOnce
+	vm := startManager(t, cfg)
+	url := "ws" + strings.TrimPrefix(newServer(t, vm.Handler()).URL, "http") + "/ws"
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dialer := &websocket.Dialer{Jar: jar}
+
+	if msg := wsVoteReply(t, dialer, url, "Candidate A"); msg.Event != eventVoteAccepted {
+		t.Fatalf("first vote: %s %s, want %s", msg.Event, msg.Data, eventVoteAccepted)
+	}
+	// A new connection carries the voter cookie set on the first one
+	if msg := wsVoteReply(t, dialer, url, "Candidate B"); msg.Event != eventError || !strings.Contains(string(msg.Data), errAlreadyVoted.Error()) {
+		t.Errorf("vote after reconnecting: %s %s, want %q", msg.Event, msg.Data, errAlreadyVoted)
+	}
+	waitFor(t, "the first vote", func() bool { return votesFor(vm, "Candidate A") == 1 })
+	if got := votesFor(vm, "Candidate B"); got != 0 {
+		t.Errorf("Candidate B has %d votes, want 0", got)
+	}
+}